@@ -0,0 +1,503 @@
+// Command loadgen puts a chat server under load. It signs up (or logs in)
+// a number of users, creates rooms, connects every user to a room over a
+// websocket and sends messages at a fixed rate, then reports connect
+// latency, fan-out latency percentiles and error counts.
+//
+//	go run ./cmd/loadgen -addr http://localhost:8080 -users 200 -rooms 10 -rate 0.5 -duration 1m
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// contentPrefix marks messages sent by this run so receivers can tell them
+// apart from history and measure how long delivery took
+const contentPrefix = "loadgen:"
+
+type config struct {
+	addr        string
+	users       int
+	rooms       int
+	rate        float64
+	duration    time.Duration
+	prefix      string
+	password    string
+	concurrency int
+}
+
+type user struct {
+	id       string
+	username string
+	cookie   *http.Cookie
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.addr, "addr", "http://localhost:8080", "base URL of the chat server")
+	flag.IntVar(&cfg.users, "users", 50, "number of users to connect")
+	flag.IntVar(&cfg.rooms, "rooms", 5, "number of rooms to spread the users over")
+	flag.Float64Var(&cfg.rate, "rate", 1, "messages per second sent by each user")
+	flag.DurationVar(&cfg.duration, "duration", 30*time.Second, "how long to send messages for")
+	flag.StringVar(&cfg.prefix, "prefix", "loadgen", "prefix for generated usernames and emails")
+	flag.StringVar(&cfg.password, "password", "loadgen-password", "password for generated users")
+	flag.IntVar(&cfg.concurrency, "concurrency", 20, "parallel signups and connection attempts")
+	flag.Parse()
+
+	if cfg.users <= 0 || cfg.rooms <= 0 || cfg.rate <= 0 || cfg.concurrency <= 0 {
+		log.Fatal("users, rooms, rate and concurrency must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, cfg config) error {
+	base, err := url.Parse(strings.TrimRight(cfg.addr, "/"))
+	if err != nil {
+		return fmt.Errorf("parse addr: %w", err)
+	}
+
+	m := newMetrics()
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	log.Printf("Signing up %d users", cfg.users)
+	users := authenticateUsers(ctx, httpClient, base, cfg, m)
+	if len(users) == 0 {
+		m.report(os.Stdout)
+		return errors.New("no users could be signed up or logged in")
+	}
+
+	log.Printf("Creating %d rooms", cfg.rooms)
+	var roomIDs []string
+	for i := 0; i < cfg.rooms; i++ {
+		id, err := createRoom(ctx, httpClient, base, fmt.Sprintf("%s room %d", cfg.prefix, i+1))
+		if err != nil {
+			m.addError("create_room")
+			log.Printf("Failed to create room: %v", err)
+			continue
+		}
+		roomIDs = append(roomIDs, id)
+	}
+	if len(roomIDs) == 0 {
+		m.report(os.Stdout)
+		return errors.New("no rooms could be created")
+	}
+
+	log.Printf("Connecting %d users to %d rooms", len(users), len(roomIDs))
+	conns := connectUsers(ctx, base, users, roomIDs, cfg, m)
+	if len(conns) == 0 {
+		m.report(os.Stdout)
+		return errors.New("no websocket connections could be opened")
+	}
+
+	// Start measuring only once everyone is connected so the numbers
+	// describe steady state, not the ramp up
+	startedAt := time.Now()
+	var readers sync.WaitGroup
+	for _, c := range conns {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			c.read(startedAt, m)
+		}()
+	}
+
+	log.Printf("Sending messages for %s", cfg.duration)
+	sendCtx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	var senders sync.WaitGroup
+	for _, c := range conns {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			c.send(sendCtx, cfg.rate, m)
+		}()
+	}
+	senders.Wait()
+
+	// Give the last messages a moment to arrive before hanging up
+	time.Sleep(2 * time.Second)
+	for _, c := range conns {
+		c.close()
+	}
+	readers.Wait()
+
+	m.elapsed = time.Since(startedAt)
+	m.report(os.Stdout)
+	return nil
+}
+
+// authenticateUsers signs up every user, falling back to logging in when
+// the account exists from an earlier run
+func authenticateUsers(ctx context.Context, client *http.Client, base *url.URL, cfg config, m *metrics) []*user {
+	var (
+		mu    sync.Mutex
+		users []*user
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, cfg.concurrency)
+	)
+
+	for i := 0; i < cfg.users; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			username := fmt.Sprintf("%s%d", cfg.prefix, i+1)
+			email := username + "@loadgen.invalid"
+
+			u, err := signup(ctx, client, base, username, email, cfg.password)
+			if err != nil {
+				u, err = login(ctx, client, base, email, cfg.password)
+				if err != nil {
+					m.addError("auth")
+					log.Printf("Failed to sign up or log in %s: %v", username, err)
+					return
+				}
+			}
+
+			mu.Lock()
+			users = append(users, u)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return users
+}
+
+func signup(ctx context.Context, client *http.Client, base *url.URL, username, email, password string) (*user, error) {
+	body := map[string]string{"username": username, "email": email, "password": password}
+	return authRequest(ctx, client, base.JoinPath("/api/users/signup").String(), body)
+}
+
+func login(ctx context.Context, client *http.Client, base *url.URL, email, password string) (*user, error) {
+	body := map[string]string{"email": email, "password": password}
+	return authRequest(ctx, client, base.JoinPath("/api/users/login").String(), body)
+}
+
+func authRequest(ctx context.Context, client *http.Client, endpoint string, body any) (*user, error) {
+	resp, err := postJSON(ctx, client, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, statusError(resp)
+	}
+
+	var res struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	u := &user{id: res.ID, username: res.Username}
+	for _, c := range resp.Cookies() {
+		if c.Name == "jwt" {
+			u.cookie = c
+		}
+	}
+	return u, nil
+}
+
+func createRoom(ctx context.Context, client *http.Client, base *url.URL, name string) (string, error) {
+	resp, err := postJSON(ctx, client, base.JoinPath("/ws/createRoom").String(), map[string]string{"name": name})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp)
+	}
+
+	var res struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	return res.ID, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return client.Do(req)
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// conn is one user's websocket connection
+type conn struct {
+	user   *user
+	ws     *websocket.Conn
+	mu     sync.Mutex
+	closed bool
+}
+
+func connectUsers(ctx context.Context, base *url.URL, users []*user, roomIDs []string, cfg config, m *metrics) []*conn {
+	wsBase := *base
+	if wsBase.Scheme == "https" {
+		wsBase.Scheme = "wss"
+	} else {
+		wsBase.Scheme = "ws"
+	}
+
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+		Subprotocols:     []string{"yappr.v1.json"},
+	}
+
+	var (
+		mu    sync.Mutex
+		conns []*conn
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, cfg.concurrency)
+	)
+
+	for i, u := range users {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			target := wsBase.JoinPath("/ws/joinRoom", roomIDs[i%len(roomIDs)])
+			q := target.Query()
+			q.Set("userId", u.id)
+			q.Set("username", u.username)
+			target.RawQuery = q.Encode()
+
+			header := http.Header{}
+			if u.cookie != nil {
+				header.Set("Cookie", u.cookie.String())
+			}
+
+			start := time.Now()
+			ws, _, err := dialer.DialContext(ctx, target.String(), header)
+			if err != nil {
+				m.addError("connect")
+				log.Printf("Failed to connect %s: %v", u.username, err)
+				return
+			}
+			m.addLatency("connect", time.Since(start))
+
+			mu.Lock()
+			conns = append(conns, &conn{user: u, ws: ws})
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return conns
+}
+
+// send writes messages at the given rate until the context is done.
+// Each message carries its send time so receivers can measure fan-out.
+func (c *conn) send(ctx context.Context, rate float64, m *metrics) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		content := contentPrefix + strconv.FormatInt(time.Now().UnixNano(), 10)
+		payload, _ := json.Marshal(map[string]string{"content": content})
+
+		c.mu.Lock()
+		err := c.ws.WriteMessage(websocket.TextMessage, payload)
+		c.mu.Unlock()
+		if err != nil {
+			m.addError("send")
+			return
+		}
+		m.sent.Add(1)
+	}
+}
+
+// read records the fan-out latency of every message from this run
+func (c *conn) read(startedAt time.Time, m *metrics) {
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			c.mu.Lock()
+			closed := c.closed
+			c.mu.Unlock()
+			if !closed {
+				m.addError("read")
+			}
+			return
+		}
+
+		var msg struct {
+			Content string `json:"content"`
+			Event   string `json:"event"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			m.addError("decode")
+			continue
+		}
+		if msg.Event == "error" {
+			m.addError("server_error_event")
+			continue
+		}
+
+		sentStr, ok := strings.CutPrefix(msg.Content, contentPrefix)
+		if !ok {
+			continue
+		}
+		sentNanos, err := strconv.ParseInt(sentStr, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// History replayed on join predates this run
+		sentAt := time.Unix(0, sentNanos)
+		if sentAt.Before(startedAt) {
+			continue
+		}
+
+		m.received.Add(1)
+		m.addLatency("fanout", time.Since(sentAt))
+	}
+}
+
+func (c *conn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+
+	_ = c.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	_ = c.ws.Close()
+}
+
+type metrics struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	sent      atomic.Int64
+	received  atomic.Int64
+	elapsed   time.Duration
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+func (m *metrics) addLatency(name string, d time.Duration) {
+	m.mu.Lock()
+	m.latencies[name] = append(m.latencies[name], d)
+	m.mu.Unlock()
+}
+
+func (m *metrics) addError(kind string) {
+	m.mu.Lock()
+	m.errors[kind]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) report(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "=== loadgen report ===")
+	if m.elapsed > 0 {
+		sent := m.sent.Load()
+		fmt.Fprintf(w, "duration:          %s\n", m.elapsed.Round(time.Millisecond))
+		fmt.Fprintf(w, "messages sent:     %d (%.1f/s)\n", sent, float64(sent)/m.elapsed.Seconds())
+		fmt.Fprintf(w, "messages received: %d\n", m.received.Load())
+	}
+
+	for _, name := range []string{"connect", "fanout"} {
+		samples := m.latencies[name]
+		if len(samples) == 0 {
+			fmt.Fprintf(w, "%-8s latency:  no samples\n", name)
+			continue
+		}
+
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		fmt.Fprintf(w, "%-8s latency:  n=%d p50=%s p90=%s p99=%s max=%s\n", name, len(samples),
+			percentile(samples, 0.50), percentile(samples, 0.90), percentile(samples, 0.99), samples[len(samples)-1])
+	}
+
+	if len(m.errors) == 0 {
+		fmt.Fprintln(w, "errors:            none")
+		return
+	}
+
+	kinds := make([]string, 0, len(m.errors))
+	for kind := range m.errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	fmt.Fprintln(w, "errors:")
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "  %-18s %d\n", kind, m.errors[kind])
+	}
+}
+
+// percentile expects samples sorted ascending
+func percentile(samples []time.Duration, p float64) time.Duration {
+	idx := int(float64(len(samples)-1) * p)
+	return samples[idx].Round(10 * time.Microsecond)
+}
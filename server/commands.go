@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	migration "github.com/momomo0206/go-chat-app/db/migrations"
+	model "github.com/momomo0206/go-chat-app/internal/api/model"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	auditService "github.com/momomo0206/go-chat-app/internal/service/audit"
+	"github.com/momomo0206/go-chat-app/internal/service/pinnedrooms"
+	service "github.com/momomo0206/go-chat-app/internal/service/user"
+	"github.com/momomo0206/go-chat-app/internal/ws"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+const usage = `usage: server [command]
+
+commands:
+  serve                          run the chat server (default)
+  migrate up|down|status|reset   apply, roll back, list or drop migrations
+  migrate to <version>           apply migrations up to a version
+  cleanup-rooms                  delete or archive expired rooms once
+  refresh-pinned [-force]        create missing pinned rooms, or new ones with -force
+  seed [-password p]             add demo users, rooms and messages
+  set-role <email> <role>        make a user a user, moderator or admin
+`
+
+// run dispatches to the subcommand named by the first argument
+func run(args []string) error {
+	if len(args) == 0 {
+		return serve()
+	}
+
+	switch args[0] {
+	case "serve":
+		return serve()
+	case "migrate":
+		return runMigrate(args[1:])
+	case "cleanup-rooms":
+		return runCleanupRooms()
+	case "refresh-pinned":
+		return runRefreshPinned(args[1:])
+	case "seed":
+		return runSeed(args[1:])
+	case "set-role":
+		return runSetRole(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// errMemoryStorage is returned by maintenance commands that would have
+// nothing to act on, since in-memory data doesn't outlive the process
+var errMemoryStorage = errors.New("ENVIRONMENT=memory keeps no data between runs, this command needs a database")
+
+// openMaintenanceStorage opens the configured database for a one-off command
+func openMaintenanceStorage() (*storage, error) {
+	env := util.GetEnv("ENVIRONMENT", "dev")
+	if env == "memory" {
+		return nil, errMemoryStorage
+	}
+
+	store, err := openStorage(env)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize storage: %w", err)
+	}
+	return store, nil
+}
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|status|reset|to <version>")
+	}
+	if util.GetEnv("ENVIRONMENT", "dev") == "memory" {
+		return errMemoryStorage
+	}
+
+	var version int64
+	if args[0] == "to" {
+		if len(args) != 2 {
+			return errors.New("usage: migrate to <version>")
+		}
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid migration version %q", args[1])
+		}
+		version = v
+	} else if len(args) != 1 {
+		return fmt.Errorf("unexpected arguments after migrate %s", args[0])
+	}
+
+	// Migrations are not applied on connect here, that is up to the subcommand
+	driver := util.GetEnv("DB_DRIVER", "postgres")
+	dbConn, err := openDatabase(driver)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	switch args[0] {
+	case "up":
+		return migration.RunMigrations(dbConn, driver)
+	case "down":
+		return migration.MigrateDown(dbConn, driver)
+	case "to":
+		return migration.MigrateToVersion(dbConn, driver, version)
+	case "status":
+		return migration.MigrateStatus(dbConn, driver)
+	case "reset":
+		return migration.MigrateReset(dbConn, driver)
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+// runCleanupRooms runs one pass of the expiry the server does every five
+// minutes, honouring ROOM_EXPIRY_MODE and ROOM_ARCHIVE_RETENTION
+func runCleanupRooms() error {
+	store, err := openMaintenanceStorage()
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	if _, err := expireRooms(context.Background(), store.rooms, loadRoomCleanupConfig()); err != nil {
+		return err
+	}
+
+	log.Println("Room cleanup completed")
+	return nil
+}
+
+func runRefreshPinned(args []string) error {
+	fs := flag.NewFlagSet("refresh-pinned", flag.ContinueOnError)
+	force := fs.Bool("force", false, "create a new set of pinned rooms even if enough are active")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := openMaintenanceStorage()
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	// The core only receives the rooms the service registers
+	wsCore := ws.NewCore(store.rooms, store.messages, store.stats)
+	go wsCore.Run()
+	defer wsCore.Stop()
+	pinnedRoomsService := pinnedrooms.NewPinnedRoomsService(store.rooms, store.topics, wsCore)
+
+	ctx := context.Background()
+	if *force {
+		return pinnedRoomsService.RefreshPinnedRooms(ctx)
+	}
+	return pinnedRoomsService.CheckAndRefreshPinnedRooms(ctx)
+}
+
+// seedUsers and seedRooms are the demo data added by the seed command
+var seedUsers = []string{"alice", "bob", "carol"}
+
+var seedRooms = []struct {
+	name     string
+	creator  string
+	category string
+	tags     []string
+	messages []string
+}{
+	{
+		name:     "Welcome Lounge",
+		creator:  "alice",
+		category: "general",
+		tags:     []string{"welcome", "introductions"},
+		messages: []string{"Hi everyone, welcome to yappr!", "Hello! Glad to be here.", "Introduce yourself when you drop by."},
+	},
+	{
+		name:     "Go Backend Talk",
+		creator:  "bob",
+		category: "tech",
+		tags:     []string{"go", "backend"},
+		messages: []string{"Anyone tried the new range-over-func iterators?", "Yes, they make custom collections much nicer."},
+	},
+	{
+		name:     "Weekend Gaming",
+		creator:  "carol",
+		category: "gaming",
+		tags:     []string{"multiplayer"},
+		messages: []string{"Who is up for a match on Saturday?", "Count me in!", "Same here, evening works best."},
+	},
+}
+
+// runSeed adds demo users, rooms and messages for local development. Users
+// that already exist are reused and creators who already have an active
+// room are skipped, so running it twice is harmless.
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	password := fs.String("password", "password123", "password for the demo users")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := openMaintenanceStorage()
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	ctx := context.Background()
+	userService := service.NewUserService(store.users, nil)
+
+	users := make(map[string]*userRepo.User, len(seedUsers))
+	for _, name := range seedUsers {
+		email := name + "@example.com"
+		user, err := store.users.GetUserByEmail(ctx, email)
+		if err != nil {
+			return fmt.Errorf("look up seed user %s: %w", name, err)
+		}
+		if user == nil {
+			if _, err := userService.CreateUser(ctx, model.RequestCreateUser{Username: name, Email: email, Password: *password}, ""); err != nil {
+				return fmt.Errorf("create seed user %s: %w", name, err)
+			}
+			if user, err = store.users.GetUserByEmail(ctx, email); err != nil || user == nil {
+				return fmt.Errorf("look up seed user %s: %w", name, err)
+			}
+			log.Printf("Created user %s <%s>", name, email)
+		}
+		users[name] = user
+	}
+
+	counts := make(map[uuid.UUID]int)
+	for _, seed := range seedRooms {
+		creator := users[seed.creator]
+		hasRoom, err := store.rooms.HasActiveRoom(ctx, creator.ID)
+		if err != nil {
+			return err
+		}
+		if hasRoom {
+			log.Printf("Skipping room %q, %s already has an active room", seed.name, seed.creator)
+			continue
+		}
+
+		category := seed.category
+		room, err := store.rooms.CreateRoom(ctx, &roomRepo.Room{
+			Name:      seed.name,
+			CreatorID: &creator.ID,
+			Category:  &category,
+			Tags:      seed.tags,
+		})
+		if err != nil {
+			return fmt.Errorf("create seed room %q: %w", seed.name, err)
+		}
+
+		// Rotate authors through the seed users, one second apart
+		start := time.Now().Add(-time.Duration(len(seed.messages)) * time.Second)
+		msgs := make([]*roomRepo.Message, len(seed.messages))
+		for i, content := range seed.messages {
+			author := users[seedUsers[i%len(seedUsers)]]
+			msgs[i] = &roomRepo.Message{
+				RoomID:        room.ID,
+				UserID:        &author.ID,
+				Username:      author.Username,
+				Content:       content,
+				CreatedAt:     start.Add(time.Duration(i) * time.Second),
+				Seq:           int64(i + 1),
+				Authenticated: true,
+			}
+			counts[author.ID]++
+		}
+		if err := store.messages.CreateMessages(ctx, msgs); err != nil {
+			return fmt.Errorf("create seed messages for %q: %w", seed.name, err)
+		}
+
+		log.Printf("Created room %q with %d messages", seed.name, len(msgs))
+	}
+
+	if err := store.stats.IncrementMessageCounts(ctx, counts); err != nil {
+		return fmt.Errorf("update seed message counts: %w", err)
+	}
+
+	log.Printf("Seed completed, log in as %s@example.com etc. with password %q", seedUsers[0], *password)
+	return nil
+}
+
+// runSetRole changes a user's role. It is how the first admin is made, since
+// the admin API can only be used by admins.
+func runSetRole(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: set-role <email> <role>")
+	}
+	email, role := args[0], args[1]
+	if !userRepo.IsRole(role) {
+		return fmt.Errorf("invalid role %q, must be one of user, moderator, admin", role)
+	}
+
+	store, err := openMaintenanceStorage()
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	ctx := context.Background()
+	user, err := store.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("look up user %s: %w", email, err)
+	}
+	if user == nil {
+		return fmt.Errorf("no user with email %s", email)
+	}
+
+	updated, err := store.users.SetUserRole(ctx, user.ID, role)
+	if err != nil {
+		return err
+	}
+
+	auditService.NewAuditService(store.audit).Record(ctx, auditService.Event{
+		Action:     auditRepo.ActionUserRoleChange,
+		TargetType: auditRepo.TargetUser,
+		TargetID:   user.ID.String(),
+		Reason:     "set-role command",
+		Before:     user,
+		After:      updated,
+	})
+	log.Printf("%s <%s> is now %s", user.Username, email, role)
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/momomo0206/go-chat-app/util"
+	_ "modernc.org/sqlite"
 )
 
 func NewDatabase() (*sql.DB, error) {
@@ -59,3 +60,26 @@ func NewDatabase() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// NewSQLiteDatabase opens the single-file database at SQLITE_PATH. Times are
+// written as text that sorts chronologically, and the pool is limited to one
+// connection since SQLite allows a single writer at a time.
+func NewSQLiteDatabase() (*sql.DB, error) {
+	path := util.GetEnv("SQLITE_PATH", "yappr.db")
+	dsn := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite",
+		path,
+	)
+
+	log.Printf("=== DATABASE CONNECTION (SQLITE) ===")
+	log.Printf("Path: %s", path)
+	log.Printf("=========================================")
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
@@ -9,17 +9,47 @@ import (
 	"github.com/pressly/goose/v3"
 )
 
-//go:embed *.sql
+// Each database driver has its own migration directory since the schemas
+// can't share SQL
+//
+//go:embed postgres/*.sql sqlite/*.sql
 var migrationsFS embed.FS
 
-func RunMigrations(db *sql.DB) error {
+// dialect pairs the goose dialect of a driver with its migration directory
+type dialect struct {
+	name string
+	dir  string
+}
+
+var dialects = map[string]dialect{
+	"postgres": {name: "postgres", dir: "postgres"},
+	"sqlite":   {name: "sqlite3", dir: "sqlite"},
+}
+
+// setup points goose at the driver's migrations and returns their directory
+func setup(driver string) (string, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return "", fmt.Errorf("unsupported database driver %q", driver)
+	}
+
 	goose.SetBaseFS(migrationsFS)
 
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("failed to set dialect: %w", err)
+	if err := goose.SetDialect(d.name); err != nil {
+		return "", fmt.Errorf("failed to set dialect: %w", err)
+	}
+
+	return d.dir, nil
+}
+
+// RunMigrations applies the pending migrations for the driver, "postgres" or "sqlite"
+func RunMigrations(db *sql.DB, driver string) error {
+	dir, err := setup(driver)
+	if err != nil {
+		return err
 	}
 
-	if err := goose.Up(db, "."); err != nil {
+	if err := goose.Up(db, dir); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
@@ -27,29 +57,29 @@ func RunMigrations(db *sql.DB) error {
 	return nil
 }
 
-func MigrateDown(db *sql.DB) error {
-	goose.SetBaseFS(migrationsFS)
-
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("failed to set dialect: %w", err)
+// MigrateDown rolls back the most recent migration
+func MigrateDown(db *sql.DB, driver string) error {
+	dir, err := setup(driver)
+	if err != nil {
+		return err
 	}
 
-	if err := goose.Down(db, "migrations"); err != nil {
+	if err := goose.Down(db, dir); err != nil {
 		return fmt.Errorf("failed to rollback migrations: %w", err)
 	}
 
-	log.Println("Migration rolleback completed successfully")
+	log.Println("Migration rollback completed successfully")
 	return nil
 }
 
-func MigrateToVersion(db *sql.DB, version int64) error {
-	goose.SetBaseFS(migrationsFS)
-
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("failed to set dialect: %w", err)
+// MigrateToVersion applies pending migrations up to and including version
+func MigrateToVersion(db *sql.DB, driver string, version int64) error {
+	dir, err := setup(driver)
+	if err != nil {
+		return err
 	}
 
-	if err := goose.UpTo(db, "migrations", version); err != nil {
+	if err := goose.UpTo(db, dir, version); err != nil {
 		return fmt.Errorf("failed to migrate to version %d: %w", version, err)
 	}
 
@@ -57,14 +87,14 @@ func MigrateToVersion(db *sql.DB, version int64) error {
 	return nil
 }
 
-func MigrateReset(db *sql.DB) error {
-	goose.SetBaseFS(migrationsFS)
-
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("failed to set dialect: %w", err)
+// MigrateReset rolls back every migration, dropping the whole schema
+func MigrateReset(db *sql.DB, driver string) error {
+	dir, err := setup(driver)
+	if err != nil {
+		return err
 	}
 
-	if err := goose.Reset(db, "migrations"); err != nil {
+	if err := goose.Reset(db, dir); err != nil {
 		return fmt.Errorf("failed to reset migrations: %w", err)
 	}
 
@@ -72,14 +102,14 @@ func MigrateReset(db *sql.DB) error {
 	return nil
 }
 
-func MigrateStatus(db *sql.DB) error {
-	goose.SetBaseFS(migrationsFS)
-
-	if err := goose.SetDialect("postgres"); err != nil {
-		return fmt.Errorf("failed to set dialect: %w", err)
+// MigrateStatus logs which migrations have been applied
+func MigrateStatus(db *sql.DB, driver string) error {
+	dir, err := setup(driver)
+	if err != nil {
+		return err
 	}
 
-	if err := goose.Status(db, "migrations"); err != nil {
+	if err := goose.Status(db, dir); err != nil {
 		return fmt.Errorf("failed to get migration status: %w", err)
 	}
 
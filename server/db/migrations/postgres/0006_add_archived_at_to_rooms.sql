@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE rooms ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+-- Create index for the archive retention purge
+CREATE INDEX idx_rooms_archived_at ON rooms(archived_at) WHERE archived_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rooms_archived_at;
+ALTER TABLE rooms DROP COLUMN archived_at;
+-- +goose StatementEnd
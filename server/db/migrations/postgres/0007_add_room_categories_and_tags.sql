@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE rooms ADD COLUMN category VARCHAR(50);
+ALTER TABLE rooms ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Create indexes for filtered listings
+CREATE INDEX idx_rooms_category ON rooms(category);
+CREATE INDEX idx_rooms_tags ON rooms USING GIN (tags);
+CREATE INDEX idx_rooms_listing ON rooms(is_pinned DESC, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rooms_listing;
+DROP INDEX IF EXISTS idx_rooms_tags;
+DROP INDEX IF EXISTS idx_rooms_category;
+ALTER TABLE rooms DROP COLUMN tags;
+ALTER TABLE rooms DROP COLUMN category;
+-- +goose StatementEnd
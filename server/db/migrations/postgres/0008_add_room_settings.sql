@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE rooms ADD COLUMN description TEXT;
+ALTER TABLE rooms ADD COLUMN rules TEXT;
+ALTER TABLE rooms ADD COLUMN slow_mode_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rooms DROP COLUMN updated_at;
+ALTER TABLE rooms DROP COLUMN slow_mode_seconds;
+ALTER TABLE rooms DROP COLUMN rules;
+ALTER TABLE rooms DROP COLUMN description;
+-- +goose StatementEnd
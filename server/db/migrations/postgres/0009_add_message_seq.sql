@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE messages ADD COLUMN seq BIGINT;
+
+-- Number existing messages in the order they were shown so far
+UPDATE messages m
+SET seq = numbered.seq
+FROM (
+  SELECT id, ROW_NUMBER() OVER (PARTITION BY room_id ORDER BY created_at, id) AS seq
+  FROM messages
+) numbered
+WHERE m.id = numbered.id;
+
+ALTER TABLE messages ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_messages_room_seq ON messages(room_id, seq);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_messages_room_seq;
+ALTER TABLE messages DROP COLUMN seq;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Abuse reports. The reported message and room are copied into the report
+-- because messages are deleted when their room expires.
+CREATE TABLE IF NOT EXISTS reports (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  reporter_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  target_type VARCHAR(10) NOT NULL CHECK (target_type IN ('message', 'user', 'room')),
+  target_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  room_id UUID, -- no foreign key, the room may be gone
+  room_name VARCHAR(255),
+  message_seq BIGINT,
+  message_username VARCHAR(255),
+  message_content TEXT,
+  message_created_at TIMESTAMP WITH TIME ZONE,
+  reason VARCHAR(20) NOT NULL,
+  details TEXT,
+  status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+  action VARCHAR(20), -- 'dismiss', 'delete_message', 'mute', 'ban'
+  resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  resolution_note TEXT,
+  resolved_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reports_status ON reports(status, created_at);
+CREATE INDEX idx_reports_reporter_id ON reports(reporter_id, created_at);
+
+-- Mutes and bans. A NULL expires_at never expires.
+CREATE TABLE IF NOT EXISTS sanctions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind VARCHAR(20) NOT NULL CHECK (kind IN ('mute', 'ban')),
+  reason TEXT,
+  report_id UUID REFERENCES reports(id) ON DELETE SET NULL,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  expires_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sanctions_user_kind ON sanctions(user_id, kind);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sanctions;
+DROP TABLE IF EXISTS reports;
+-- +goose StatementEnd
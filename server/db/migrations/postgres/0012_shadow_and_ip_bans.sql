@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sanctions DROP CONSTRAINT IF EXISTS sanctions_kind_check;
+ALTER TABLE sanctions ADD CONSTRAINT sanctions_kind_check
+  CHECK (kind IN ('mute', 'ban', 'shadow_ban'));
+
+-- Sanctions can be lifted before they expire
+ALTER TABLE sanctions ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sanctions ADD COLUMN revoked_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Bans on an address or network. cidr is stored as text and matched in Go,
+-- a single address is stored as a /32 or /128 prefix.
+CREATE TABLE IF NOT EXISTS ip_bans (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  cidr VARCHAR(50) NOT NULL,
+  reason TEXT NOT NULL,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  expires_at TIMESTAMP WITH TIME ZONE,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+  revoked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ip_bans_active ON ip_bans(expires_at) WHERE revoked_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ip_bans;
+
+ALTER TABLE sanctions DROP COLUMN revoked_by;
+ALTER TABLE sanctions DROP COLUMN revoked_at;
+
+DELETE FROM sanctions WHERE kind = 'shadow_ban';
+ALTER TABLE sanctions DROP CONSTRAINT IF EXISTS sanctions_kind_check;
+ALTER TABLE sanctions ADD CONSTRAINT sanctions_kind_check CHECK (kind IN ('mute', 'ban'));
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Append-only record of every moderation action. actor_id has no foreign
+-- key so entries outlive the accounts they mention; a NULL actor_id is an
+-- automatic action or a maintenance command.
+CREATE TABLE IF NOT EXISTS moderation_audit_log (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  actor_id UUID,
+  action VARCHAR(50) NOT NULL,
+  target_type VARCHAR(20) NOT NULL,
+  target_id VARCHAR(100) NOT NULL,
+  reason TEXT,
+  before JSONB,
+  after JSONB,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_moderation_audit_log_created_at ON moderation_audit_log(created_at);
+CREATE INDEX idx_moderation_audit_log_actor_id ON moderation_audit_log(actor_id, created_at);
+CREATE INDEX idx_moderation_audit_log_target ON moderation_audit_log(target_type, target_id, created_at);
+
+CREATE OR REPLACE FUNCTION reject_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'moderation_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER moderation_audit_log_append_only
+  BEFORE UPDATE OR DELETE ON moderation_audit_log
+  FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS moderation_audit_log;
+DROP FUNCTION IF EXISTS reject_audit_log_change();
+-- +goose StatementEnd
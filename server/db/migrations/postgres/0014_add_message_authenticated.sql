@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Whether user_id came from the sender's token. Older messages took it from
+-- the connection's query string, so they aren't trusted.
+ALTER TABLE messages ADD COLUMN authenticated BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE messages DROP COLUMN authenticated;
+-- +goose StatementEnd
@@ -0,0 +1,131 @@
+-- SQLite has no uuid_generate_v4, NOW() or intervals. IDs are random v4
+-- UUIDs built from randomblob and timestamps are UTC text in the format the
+-- Go driver writes, so they compare correctly as strings.
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS users (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  username TEXT UNIQUE NOT NULL,
+  email TEXT UNIQUE NOT NULL,
+  password_hash TEXT,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+  updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS rooms (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  name TEXT NOT NULL,
+  creator_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+  expires_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now', '+24 hours')),
+  is_pinned BOOLEAN NOT NULL DEFAULT 0,
+  topic_title TEXT,
+  topic_description TEXT,
+  topic_url TEXT,
+  topic_source TEXT,
+  topic_updated_at DATETIME,
+  archived_at DATETIME,
+  category TEXT,
+  tags TEXT NOT NULL DEFAULT '[]', -- JSON array
+  description TEXT,
+  rules TEXT,
+  slow_mode_seconds INTEGER NOT NULL DEFAULT 0,
+  updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_rooms_expires_at ON rooms(expires_at);
+CREATE INDEX idx_rooms_creator_id ON rooms(creator_id);
+CREATE INDEX idx_rooms_is_pinned ON rooms(is_pinned) WHERE is_pinned = 1;
+CREATE INDEX idx_rooms_archived_at ON rooms(archived_at) WHERE archived_at IS NOT NULL;
+CREATE INDEX idx_rooms_listing ON rooms(category, is_pinned, created_at);
+
+CREATE TABLE IF NOT EXISTS messages (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  room_id TEXT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+  user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+  username TEXT NOT NULL,
+  content TEXT NOT NULL,
+  is_system BOOLEAN NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+  seq INTEGER NOT NULL
+);
+
+CREATE INDEX idx_messages_room_id ON messages(room_id);
+CREATE INDEX idx_messages_created_at ON messages(room_id, created_at);
+CREATE UNIQUE INDEX idx_messages_room_seq ON messages(room_id, seq);
+
+CREATE TABLE IF NOT EXISTS user_stats (
+  user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  daily_streak INTEGER NOT NULL DEFAULT 0,
+  total_checkins INTEGER NOT NULL DEFAULT 0,
+  total_messages INTEGER NOT NULL DEFAULT 0,
+  total_upvotes_received INTEGER NOT NULL DEFAULT 0,
+  last_checkin_date DATETIME,
+  last_upvote_given_date DATETIME,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+  updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS daily_checkins (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  checkin_date DATETIME NOT NULL,
+  streak_count INTEGER NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS upvotes (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  from_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  to_user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+  UNIQUE(from_user_id, to_user_id),
+  CHECK(from_user_id != to_user_id)
+);
+
+CREATE TABLE IF NOT EXISTS achievement_types (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL,
+  icon TEXT NOT NULL,
+  threshold_type TEXT NOT NULL, -- 'streak', 'messages', 'upvotes'
+  threshold_value INTEGER NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS user_achievements (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  achievement_type_id TEXT NOT NULL REFERENCES achievement_types(id) ON DELETE CASCADE,
+  earned_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+  UNIQUE(user_id, achievement_type_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_checkins_user_id ON daily_checkins(user_id);
+CREATE INDEX IF NOT EXISTS idx_daily_checkins_date ON daily_checkins(checkin_date);
+CREATE INDEX IF NOT EXISTS idx_upvotes_from_user ON upvotes(from_user_id);
+CREATE INDEX IF NOT EXISTS idx_upvotes_to_user ON upvotes(to_user_id);
+CREATE INDEX IF NOT EXISTS idx_user_achievements_user_id ON user_achievements(user_id);
+
+INSERT OR IGNORE INTO achievement_types (name, description, icon, threshold_type, threshold_value) VALUES
+  ('First Steps', 'Complete your first daily check-in', '🌟', 'streak', 1),
+  ('Weekly Warrior', 'Maintain a 7-day streak', '🔥', 'streak', 7),
+  ('Monthly Master', 'Maintain a 30-day streak', '👑', 'streak', 30),
+  ('Chatter', 'Send your first messages', '💬', 'messages', 10),
+  ('Conversationalist', 'Send 100 messages', '🗣️', 'messages', 100),
+  ('Popular', 'Receive your first 5 upvotes', '✨', 'upvotes', 5),
+  ('Beloved', 'Receive 25 upvotes', '💖', 'upvotes', 25);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_achievements;
+DROP TABLE IF EXISTS achievement_types;
+DROP TABLE IF EXISTS upvotes;
+DROP TABLE IF EXISTS daily_checkins;
+DROP TABLE IF EXISTS user_stats;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd
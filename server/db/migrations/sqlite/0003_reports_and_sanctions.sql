@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Abuse reports. The reported message and room are copied into the report
+-- because messages are deleted when their room expires.
+CREATE TABLE IF NOT EXISTS reports (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  reporter_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+  target_type TEXT NOT NULL CHECK (target_type IN ('message', 'user', 'room')),
+  target_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+  room_id TEXT, -- no foreign key, the room may be gone
+  room_name TEXT,
+  message_seq INTEGER,
+  message_username TEXT,
+  message_content TEXT,
+  message_created_at DATETIME,
+  reason TEXT NOT NULL,
+  details TEXT,
+  status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+  action TEXT, -- 'dismiss', 'delete_message', 'mute', 'ban'
+  resolved_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  resolution_note TEXT,
+  resolved_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_reports_status ON reports(status, created_at);
+CREATE INDEX idx_reports_reporter_id ON reports(reporter_id, created_at);
+
+-- Mutes and bans. A NULL expires_at never expires.
+CREATE TABLE IF NOT EXISTS sanctions (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('mute', 'ban')),
+  reason TEXT,
+  report_id TEXT REFERENCES reports(id) ON DELETE SET NULL,
+  created_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  expires_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_sanctions_user_kind ON sanctions(user_id, kind);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sanctions;
+DROP TABLE IF EXISTS reports;
+-- +goose StatementEnd
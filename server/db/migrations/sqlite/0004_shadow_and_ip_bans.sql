@@ -0,0 +1,65 @@
+-- SQLite can't alter a CHECK constraint, so sanctions is rebuilt to allow
+-- the shadow_ban kind.
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE sanctions_new (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('mute', 'ban', 'shadow_ban')),
+  reason TEXT,
+  report_id TEXT REFERENCES reports(id) ON DELETE SET NULL,
+  created_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  expires_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+  -- Sanctions can be lifted before they expire
+  revoked_at DATETIME,
+  revoked_by TEXT REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO sanctions_new (id, user_id, kind, reason, report_id, created_by, expires_at, created_at)
+SELECT id, user_id, kind, reason, report_id, created_by, expires_at, created_at FROM sanctions;
+
+DROP TABLE sanctions;
+ALTER TABLE sanctions_new RENAME TO sanctions;
+CREATE INDEX idx_sanctions_user_kind ON sanctions(user_id, kind);
+
+-- Bans on an address or network. cidr is matched in Go, a single address is
+-- stored as a /32 or /128 prefix.
+CREATE TABLE IF NOT EXISTS ip_bans (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  cidr TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  created_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  expires_at DATETIME,
+  revoked_at DATETIME,
+  revoked_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_ip_bans_active ON ip_bans(expires_at) WHERE revoked_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ip_bans;
+
+CREATE TABLE sanctions_old (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('mute', 'ban')),
+  reason TEXT,
+  report_id TEXT REFERENCES reports(id) ON DELETE SET NULL,
+  created_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+  expires_at DATETIME,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+INSERT INTO sanctions_old (id, user_id, kind, reason, report_id, created_by, expires_at, created_at)
+SELECT id, user_id, kind, reason, report_id, created_by, expires_at, created_at FROM sanctions
+WHERE kind != 'shadow_ban';
+
+DROP TABLE sanctions;
+ALTER TABLE sanctions_old RENAME TO sanctions;
+CREATE INDEX idx_sanctions_user_kind ON sanctions(user_id, kind);
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Append-only record of every moderation action. actor_id has no foreign
+-- key so entries outlive the accounts they mention; a NULL actor_id is an
+-- automatic action or a maintenance command.
+CREATE TABLE IF NOT EXISTS moderation_audit_log (
+  id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+  actor_id TEXT,
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id TEXT NOT NULL,
+  reason TEXT,
+  before TEXT,
+  after TEXT,
+  created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX idx_moderation_audit_log_created_at ON moderation_audit_log(created_at);
+CREATE INDEX idx_moderation_audit_log_actor_id ON moderation_audit_log(actor_id, created_at);
+CREATE INDEX idx_moderation_audit_log_target ON moderation_audit_log(target_type, target_id, created_at);
+
+CREATE TRIGGER moderation_audit_log_no_update
+  BEFORE UPDATE ON moderation_audit_log
+  BEGIN
+    SELECT RAISE(ABORT, 'moderation_audit_log is append-only');
+  END;
+
+CREATE TRIGGER moderation_audit_log_no_delete
+  BEFORE DELETE ON moderation_audit_log
+  BEGIN
+    SELECT RAISE(ABORT, 'moderation_audit_log is append-only');
+  END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS moderation_audit_log;
+-- +goose StatementEnd
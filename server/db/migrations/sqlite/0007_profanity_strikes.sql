@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Profanity strikes against a user ("user:<id>") or an address ("ip:<addr>").
+-- Rows past expires_at have fully decayed and are deleted.
+CREATE TABLE IF NOT EXISTS profanity_strikes (
+  key TEXT PRIMARY KEY,
+  count INTEGER NOT NULL,
+  updated_at DATETIME NOT NULL,
+  expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_profanity_strikes_expires_at ON profanity_strikes(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS profanity_strikes;
+-- +goose StatementEnd
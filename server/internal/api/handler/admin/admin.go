@@ -0,0 +1,456 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/internal/api/model"
+	"github.com/momomo0206/go-chat-app/internal/filter"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+	statsRepo "github.com/momomo0206/go-chat-app/internal/repo/stats"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	auditService "github.com/momomo0206/go-chat-app/internal/service/audit"
+	"github.com/momomo0206/go-chat-app/internal/ws"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+// AdminHandler serves the /api/admin endpoints. The router only lets admins
+// reach it.
+type AdminHandler struct {
+	core        *ws.Core
+	userRepo    userRepo.UserStore
+	roomRepo    roomRepo.RoomStore
+	messageRepo roomRepo.MessageStore
+	statsRepo   statsRepo.StatsStore
+	audit       *auditService.AuditService
+	profanity   *filter.ProfanityFilter
+}
+
+func NewAdminHandler(c *ws.Core, users userRepo.UserStore, rooms roomRepo.RoomStore, messages roomRepo.MessageStore, stats statsRepo.StatsStore, audit *auditService.AuditService, profanity *filter.ProfanityFilter) *AdminHandler {
+	return &AdminHandler{
+		core:        c,
+		userRepo:    users,
+		roomRepo:    rooms,
+		messageRepo: messages,
+		statsRepo:   stats,
+		audit:       audit,
+		profanity:   profanity,
+	}
+}
+
+// record adds an admin action to the audit log. Admins can explain any
+// action with a ?reason= query parameter.
+func (h *AdminHandler) record(r *http.Request, action, targetType, targetID string, before, after any) {
+	event := auditService.Event{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     strings.TrimSpace(r.URL.Query().Get("reason")),
+		Before:     before,
+		After:      after,
+	}
+	if userIDStr, ok := r.Context().Value("userID").(string); ok {
+		if adminID, err := uuid.Parse(userIDStr); err == nil {
+			event.ActorID = &adminID
+		}
+	}
+
+	h.audit.Record(r.Context(), event)
+}
+
+// pathUUID parses a UUID URL parameter, writing a 400 if it is malformed
+func pathUUID(w http.ResponseWriter, r *http.Request, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid "+param)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// queryInt parses a non-negative integer query parameter
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid " + key)
+	}
+	return n, nil
+}
+
+// ListUsers returns a page of users, optionally filtered by ?search= on
+// username or email
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, err := queryInt(r, "limit", defaultUserPageSize)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = defaultUserPageSize
+	}
+	limit = min(limit, maxUserPageSize)
+
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, total, err := h.userRepo.SearchUsers(ctx, r.URL.Query().Get("search"), limit, offset)
+	if err != nil {
+		log.Printf("Error searching users: %v", err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+	if users == nil {
+		users = []*userRepo.User{}
+	}
+
+	totalUsers, err := h.userRepo.CountUsers(ctx)
+	if err != nil {
+		log.Printf("Error counting users: %v", err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, model.AdminUsersResponse{
+		Users:      users,
+		Total:      total,
+		TotalUsers: totalUsers,
+		Limit:      limit,
+		Offset:     offset,
+	})
+}
+
+// DeleteUser removes an account. Its messages stay, attributed to the
+// username they were sent under, unless purged first.
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	if userID.String() == r.Context().Value("userID") {
+		util.WriteError(w, http.StatusBadRequest, "admins cannot delete their own account")
+		return
+	}
+
+	before, err := h.userRepo.GetUserById(r.Context(), userID)
+	if err != nil {
+		util.WriteError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if err := h.userRepo.DeleteUser(r.Context(), userID); err != nil {
+		if err.Error() == "user not found" {
+			util.WriteError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		log.Printf("Error deleting user %s: %v", userID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to delete user")
+		return
+	}
+
+	if _, err := h.core.DisconnectUser(r.Context(), userID.String(), "Your account has been deleted", ws.CloseAccountDeleted, "account deleted"); err != nil {
+		log.Printf("Error disconnecting deleted user %s: %v", userID, err)
+	}
+
+	h.record(r, auditRepo.ActionUserDelete, auditRepo.TargetUser, userID.String(), before, nil)
+	log.Printf("Admin %s deleted user %s", r.Context().Value("userID"), userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetUserRole grants or revokes moderator and admin rights
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	var req model.SetUserRoleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	if !userRepo.IsRole(req.Role) {
+		util.WriteError(w, http.StatusBadRequest, "role must be one of user, moderator, admin")
+		return
+	}
+
+	// Keep admins from locking themselves out
+	if userID.String() == r.Context().Value("userID") && req.Role != userRepo.RoleAdmin {
+		util.WriteError(w, http.StatusBadRequest, "admins cannot remove their own admin role")
+		return
+	}
+
+	before, err := h.userRepo.GetUserById(r.Context(), userID)
+	if err != nil {
+		util.WriteError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	user, err := h.userRepo.SetUserRole(r.Context(), userID, req.Role)
+	if err != nil {
+		if err.Error() == "user not found" {
+			util.WriteError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		log.Printf("Error setting role of user %s: %v", userID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to set role")
+		return
+	}
+
+	h.record(r, auditRepo.ActionUserRoleChange, auditRepo.TargetUser, userID.String(), before, user)
+	log.Printf("Admin %s set role of user %s to %s", r.Context().Value("userID"), userID, req.Role)
+	util.WriteJSON(w, http.StatusOK, user)
+}
+
+// DeleteUserMessages purges every message the user sent, in every room
+func (h *AdminHandler) DeleteUserMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	// Messages still queued for the database would be written after the purge
+	if err := h.core.FlushMessages(r.Context()); err != nil {
+		log.Printf("Error flushing messages before purging user %s: %v", userID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to delete messages")
+		return
+	}
+
+	deleted, err := h.messageRepo.DeleteUserMessages(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error deleting messages of user %s: %v", userID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to delete messages")
+		return
+	}
+
+	if err := h.core.PurgeUserHistory(r.Context(), userID.String()); err != nil {
+		log.Printf("Error purging live history of user %s: %v", userID, err)
+	}
+
+	h.record(r, auditRepo.ActionUserMessagesPurge, auditRepo.TargetUser, userID.String(), nil, map[string]int{"deleted": deleted})
+	log.Printf("Admin %s deleted %d messages of user %s", r.Context().Value("userID"), deleted, userID)
+	util.WriteJSON(w, http.StatusOK, map[string]int{"deleted": deleted})
+}
+
+// DeleteRoom removes a room, active or archived, with all its messages and
+// disconnects its clients
+func (h *AdminHandler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathUUID(w, r, "roomId")
+	if !ok {
+		return
+	}
+
+	before, err := h.roomRepo.GetRoomByIDIncludingArchived(r.Context(), roomID)
+	if err != nil {
+		log.Printf("Error getting room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to delete room")
+		return
+	}
+
+	deleted, err := h.roomRepo.DeleteRoom(r.Context(), roomID)
+	if err != nil {
+		log.Printf("Error deleting room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to delete room")
+		return
+	}
+
+	disconnected, err := h.core.DeleteRoom(r.Context(), roomID.String())
+	if err != nil {
+		log.Printf("Error closing live room %s: %v", roomID, err)
+	}
+
+	if !deleted && disconnected == 0 {
+		util.WriteError(w, http.StatusNotFound, "room not found")
+		return
+	}
+
+	h.record(r, auditRepo.ActionRoomDelete, auditRepo.TargetRoom, roomID.String(), before, map[string]int{"disconnected": disconnected})
+	log.Printf("Admin %s deleted room %s", r.Context().Value("userID"), roomID)
+	util.WriteJSON(w, http.StatusOK, map[string]int{"disconnected": disconnected})
+}
+
+// ExpireRoom ends an active room now. It is then deleted or archived by the
+// cleanup job like a room that reached its expiry.
+func (h *AdminHandler) ExpireRoom(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathUUID(w, r, "roomId")
+	if !ok {
+		return
+	}
+
+	before, err := h.roomRepo.GetRoomByIDIncludingArchived(r.Context(), roomID)
+	if err != nil {
+		log.Printf("Error getting room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to expire room")
+		return
+	}
+
+	expired, err := h.roomRepo.ExpireRoom(r.Context(), roomID)
+	if err != nil {
+		log.Printf("Error expiring room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to expire room")
+		return
+	}
+	if !expired {
+		util.WriteError(w, http.StatusNotFound, "room not found or not active")
+		return
+	}
+
+	disconnected, err := h.core.ExpireRoom(r.Context(), roomID.String())
+	if err != nil {
+		log.Printf("Error closing live room %s: %v", roomID, err)
+	}
+
+	after, err := h.roomRepo.GetRoomByIDIncludingArchived(r.Context(), roomID)
+	if err != nil {
+		log.Printf("Error getting expired room %s: %v", roomID, err)
+	}
+
+	h.record(r, auditRepo.ActionRoomExpire, auditRepo.TargetRoom, roomID.String(), before, after)
+	log.Printf("Admin %s expired room %s", r.Context().Value("userID"), roomID)
+	util.WriteJSON(w, http.StatusOK, map[string]int{"disconnected": disconnected})
+}
+
+// DeleteRoomMessages purges a room's history but leaves the room open
+func (h *AdminHandler) DeleteRoomMessages(w http.ResponseWriter, r *http.Request) {
+	roomID, ok := pathUUID(w, r, "roomId")
+	if !ok {
+		return
+	}
+
+	// Messages still queued for the database would be written after the purge
+	if err := h.core.FlushMessages(r.Context()); err != nil {
+		log.Printf("Error flushing messages before purging room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to delete messages")
+		return
+	}
+
+	deleted, err := h.messageRepo.DeleteRoomMessages(r.Context(), roomID)
+	if err != nil {
+		log.Printf("Error deleting messages of room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to delete messages")
+		return
+	}
+
+	if err := h.core.PurgeRoomHistory(r.Context(), roomID.String()); err != nil {
+		log.Printf("Error purging live history of room %s: %v", roomID, err)
+	}
+
+	h.record(r, auditRepo.ActionRoomMessagesPurge, auditRepo.TargetRoom, roomID.String(), nil, map[string]int{"deleted": deleted})
+	log.Printf("Admin %s deleted %d messages in room %s", r.Context().Value("userID"), deleted, roomID)
+	util.WriteJSON(w, http.StatusOK, map[string]int{"deleted": deleted})
+}
+
+// GetAchievementTypes lists the achievements that can be granted
+func (h *AdminHandler) GetAchievementTypes(w http.ResponseWriter, r *http.Request) {
+	achievements, err := h.statsRepo.GetAchievementTypes(r.Context())
+	if err != nil {
+		log.Printf("Error getting achievement types: %v", err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to get achievements")
+		return
+	}
+	if achievements == nil {
+		achievements = []statsRepo.Achievement{}
+	}
+
+	util.WriteJSON(w, http.StatusOK, achievements)
+}
+
+// GrantAchievement awards an achievement whether or not the user reached its threshold
+func (h *AdminHandler) GrantAchievement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	var req model.GrantAchievementReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	if _, err := h.userRepo.GetUserById(r.Context(), userID); err != nil {
+		util.WriteError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	granted, err := h.statsRepo.GrantAchievement(r.Context(), userID, req.AchievementID)
+	if err != nil {
+		if errors.Is(err, statsRepo.ErrAchievementNotFound) {
+			util.WriteError(w, http.StatusNotFound, "achievement not found")
+			return
+		}
+		log.Printf("Error granting achievement %s to user %s: %v", req.AchievementID, userID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to grant achievement")
+		return
+	}
+	if !granted {
+		util.WriteError(w, http.StatusConflict, "user already has this achievement")
+		return
+	}
+
+	h.record(r, auditRepo.ActionAchievementGrant, auditRepo.TargetUser, userID.String(), nil, map[string]uuid.UUID{"achievement_id": req.AchievementID})
+	log.Printf("Admin %s granted achievement %s to user %s", r.Context().Value("userID"), req.AchievementID, userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAchievement takes an earned achievement away from the user. It can
+// be earned again by reaching its threshold.
+func (h *AdminHandler) RevokeAchievement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "userId")
+	if !ok {
+		return
+	}
+	achievementID, ok := pathUUID(w, r, "achievementId")
+	if !ok {
+		return
+	}
+
+	revoked, err := h.statsRepo.RevokeAchievement(r.Context(), userID, achievementID)
+	if err != nil {
+		log.Printf("Error revoking achievement %s from user %s: %v", achievementID, userID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to revoke achievement")
+		return
+	}
+	if !revoked {
+		util.WriteError(w, http.StatusNotFound, "user does not have this achievement")
+		return
+	}
+
+	h.record(r, auditRepo.ActionAchievementRevoke, auditRepo.TargetUser, userID.String(), map[string]uuid.UUID{"achievement_id": achievementID}, nil)
+	log.Printf("Admin %s revoked achievement %s from user %s", r.Context().Value("userID"), achievementID, userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetConnections reports the live rooms and how many clients each has
+func (h *AdminHandler) GetConnections(w http.ResponseWriter, r *http.Request) {
+	conns, err := h.core.Connections(r.Context())
+	if err != nil {
+		util.WriteError(w, http.StatusServiceUnavailable, "websocket core did not respond")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, conns)
+}
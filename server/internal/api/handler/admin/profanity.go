@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/momomo0206/go-chat-app/internal/api/model"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+// GetWordLists describes the profanity word lists in use
+func (h *AdminHandler) GetWordLists(w http.ResponseWriter, r *http.Request) {
+	util.WriteJSON(w, http.StatusOK, h.profanity.Stats())
+}
+
+// ReloadWordLists reads the profanity word list file again without waiting
+// for the next change check. A broken file leaves the current lists in use.
+func (h *AdminHandler) ReloadWordLists(w http.ResponseWriter, r *http.Request) {
+	before := h.profanity.Stats()
+
+	stats, err := h.profanity.Reload()
+	if err != nil {
+		log.Printf("Error reloading word lists: %v", err)
+		util.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	h.record(r, auditRepo.ActionWordListReload, auditRepo.TargetWordList, stats.Source, before, stats)
+	util.WriteJSON(w, http.StatusOK, stats)
+}
+
+// ExplainProfanity is a dry run of the profanity filter. It shows which
+// rules match the text and why it would or would not be blocked, without
+// counting a strike.
+func (h *AdminHandler) ExplainProfanity(w http.ResponseWriter, r *http.Request) {
+	var req model.ExplainProfanityReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+	if req.Text == "" {
+		util.WriteError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, h.profanity.Explain(req.Text))
+}
@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -12,18 +20,25 @@ import (
 	"github.com/momomo0206/go-chat-app/internal/api/model"
 	"github.com/momomo0206/go-chat-app/internal/filter"
 	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	moderationService "github.com/momomo0206/go-chat-app/internal/service/moderation"
+	"github.com/momomo0206/go-chat-app/internal/service/transcript"
 	"github.com/momomo0206/go-chat-app/internal/ws"
 	"github.com/momomo0206/go-chat-app/util"
 )
 
 type CoreHandler struct {
-	core            *ws.Core
-	roomRepo        *roomRepo.RoomRepository
-	roomLimit       int
-	profanityFilter *filter.ProfanityFilter
+	core              *ws.Core
+	userRepo          userRepo.UserStore
+	roomRepo          roomRepo.RoomStore
+	messageRepo       roomRepo.MessageStore
+	transcriptService *transcript.TranscriptService
+	roomLimit         int
+	profanityFilter   *filter.ProfanityFilter
+	moderationService *moderationService.ModerationService
 }
 
-func NewCoreHandler(c *ws.Core) *CoreHandler {
+func NewCoreHandler(c *ws.Core, users userRepo.UserStore, rooms roomRepo.RoomStore, messages roomRepo.MessageStore, moderation *moderationService.ModerationService, profanity *filter.ProfanityFilter) *CoreHandler {
 	// Default room limit is 100, can be overridden by MAX_ROOMS env var
 	roomLimit := 50
 	if maxRoomsStr := util.GetEnv("MAX_ROOMS", ""); maxRoomsStr != "" {
@@ -33,13 +48,93 @@ func NewCoreHandler(c *ws.Core) *CoreHandler {
 	}
 
 	return &CoreHandler{
-		core:            c,
-		roomRepo:        roomRepo.NewRoomRepository(c.GetDB()),
-		roomLimit:       roomLimit,
-		profanityFilter: filter.NewProfanityFilter(),
+		core:              c,
+		userRepo:          users,
+		roomRepo:          rooms,
+		messageRepo:       messages,
+		transcriptService: transcript.NewTranscriptService(messages),
+		roomLimit:         roomLimit,
+		profanityFilter:   profanity,
+		moderationService: moderation,
 	}
 }
 
+const (
+	maxRoomTags   = 5
+	maxRoomTagLen = 30
+)
+
+// normalizeTag lowercases a tag and strips whitespace and a leading '#'
+func normalizeTag(tag string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(tag)), "#")
+}
+
+// normalizeTags cleans up and de-duplicates the tags of a room
+func normalizeTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool)
+
+	for _, t := range raw {
+		tag := normalizeTag(t)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxRoomTagLen {
+			return nil, fmt.Errorf("tags must be at most %d characters", maxRoomTagLen)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > maxRoomTags {
+		return nil, fmt.Errorf("a room can have at most %d tags", maxRoomTags)
+	}
+
+	return tags, nil
+}
+
+// newMemoryRoom builds the websocket core's view of a database room
+func newMemoryRoom(room *roomRepo.Room) *ws.Room {
+	creatorID := ""
+	if room.CreatorID != nil {
+		creatorID = room.CreatorID.String()
+	}
+
+	return &ws.Room{
+		ID:               room.ID.String(),
+		Name:             room.Name,
+		Clients:          make(map[string]*ws.Client),
+		IsPinned:         room.IsPinned,
+		TopicTitle:       room.TopicTitle,
+		TopicDescription: room.TopicDescription,
+		TopicURL:         room.TopicURL,
+		TopicSource:      room.TopicSource,
+		ExpiresAt:        room.ExpiresAt,
+		CreatorID:        creatorID,
+		Description:      room.Description,
+		Rules:            room.Rules,
+		SlowModeSeconds:  room.SlowModeSeconds,
+		Archived:         room.IsArchived(),
+		LastSeq:          room.LastSeq,
+	}
+}
+
+// rejectProfanity counts content blocked by the profanity filter against the
+// requester and responds with msg and the warning, mute or ban it led to
+func (h *CoreHandler) rejectProfanity(w http.ResponseWriter, r *http.Request, source, msg string) {
+	var userID uuid.UUID
+	if userIDStr, ok := r.Context().Value("userID").(string); ok {
+		userID, _ = uuid.Parse(userIDStr)
+	}
+
+	strike := h.moderationService.RecordProfanityStrike(r.Context(), userID, util.ClientIP(r), source)
+	util.WriteJSON(w, http.StatusBadRequest, map[string]any{
+		"error":  msg,
+		"notice": strike.Notice(),
+		"strike": strike,
+	})
+}
+
 func (h *CoreHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateRoomReq
 
@@ -56,10 +151,34 @@ func (h *CoreHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	// Check for profanity in room name
 	if h.profanityFilter.ContainsProfanity(req.Name) {
 		log.Printf("Room creation blocked - inapproproate name: %s", req.Name)
-		util.WriteError(w, http.StatusBadRequest, "room name contains inappropriate content")
+		h.rejectProfanity(w, r, moderationService.StrikeRoomName, "room name contains inappropriate content")
+		return
+	}
+
+	var category *string
+	req.Category = strings.ToLower(strings.TrimSpace(req.Category))
+	if req.Category != "" {
+		if !model.IsRoomCategory(req.Category) {
+			util.WriteError(w, http.StatusBadRequest, "unknown category")
+			return
+		}
+		category = &req.Category
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	for _, tag := range tags {
+		if h.profanityFilter.ContainsProfanity(tag) {
+			log.Printf("Room creation blocked - inappropriate tag: %s", tag)
+			h.rejectProfanity(w, r, moderationService.StrikeRoomTag, "room tags contain inappropriate content")
+			return
+		}
+	}
+
 	ctx := r.Context()
 
 	// Get user ID from context (if authenticated)
@@ -88,6 +207,16 @@ func (h *CoreHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 		log.Printf("No user ID in context (anonymous user)")
 	}
 
+	// Guests are only checked by address
+	accessUserID := uuid.Nil
+	if creatorID != nil {
+		accessUserID = *creatorID
+	}
+	if err := h.moderationService.CheckAccess(accessUserID, util.ClientIP(r)); err != nil {
+		util.WriteError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
 	// Check global room limit
 	activeRooms, err := h.roomRepo.CountActiveRooms(ctx)
 	if err != nil {
@@ -107,6 +236,8 @@ func (h *CoreHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	room := &roomRepo.Room{
 		Name:      req.Name,
 		CreatorID: creatorID,
+		Category:  category,
+		Tags:      tags,
 	}
 	room, err = h.roomRepo.CreateRoom(ctx, room)
 	if err != nil {
@@ -118,21 +249,16 @@ func (h *CoreHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Room created with ID: %s", room.ID.String())
 
 	// Add to in-memory map
-	h.core.Rooms[room.ID.String()] = &ws.Room{
-		ID:               room.ID.String(),
-		Name:             room.Name,
-		Clients:          make(map[string]*ws.Client),
-		IsPinned:         room.IsPinned,
-		TopicTitle:       room.TopicTitle,
-		TopicDescription: room.TopicDescription,
-		TopicURL:         room.TopicURL,
-		TopicSource:      room.TopicSource,
+	if err := h.core.AddRooms(ctx, newMemoryRoom(room)); err != nil {
+		log.Printf("Error loading new room %s: %v", room.ID, err)
 	}
 
 	// Return the room with the database-genarated ID
 	resp := model.CreateRoomReq{
-		ID:   room.ID.String(),
-		Name: room.Name,
+		ID:       room.ID.String(),
+		Name:     room.Name,
+		Category: req.Category,
+		Tags:     room.Tags,
 	}
 	util.WriteJSON(w, http.StatusOK, resp)
 }
@@ -140,6 +266,11 @@ func (h *CoreHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 func (h *CoreHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 	roomID := chi.URLParam(r, "roomId") // from /ws/{roomId}
 
+	if h.core.Draining() {
+		util.WriteError(w, http.StatusServiceUnavailable, "server is restarting")
+		return
+	}
+
 	// Verify room exists in database
 	roomUUID, err := uuid.Parse(roomID)
 	if err != nil {
@@ -148,7 +279,7 @@ func (h *CoreHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	dbRoom, err := h.roomRepo.GetRoomByID(ctx, roomUUID)
+	dbRoom, err := h.roomRepo.GetRoomByIDIncludingArchived(ctx, roomUUID)
 	if err != nil {
 		util.WriteError(w, http.StatusInternalServerError, "failed to Verify room")
 		return
@@ -158,17 +289,86 @@ func (h *CoreHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Signed-in clients are identified by their token and userId, if sent,
+	// must name the same user. Guests get an ID that can't be mistaken for a
+	// user's; the userId they send is ignored.
+	q := r.URL.Query()
+	tokenUserID, authenticated := ctx.Value("userID").(string)
+	if userID := q.Get("userId"); authenticated && userID != "" && userID != tokenUserID {
+		util.WriteError(w, http.StatusForbidden, "userId does not match the signed-in user")
+		return
+	}
+	clientID := ws.NewGuestID()
+	if authenticated {
+		clientID = tokenUserID
+	}
+	username := q.Get("username")
+
+	// Reconnecting clients pass the last sequence number they saw to receive
+	// only what they missed
+	var resumeAfter int64
+	if since := q.Get("since"); since != "" {
+		resumeAfter, err = strconv.ParseInt(since, 10, 64)
+		if err != nil || resumeAfter < 0 {
+			util.WriteError(w, http.StatusBadRequest, "invalid since")
+			return
+		}
+	}
+
+	if h.profanityFilter.ContainsProfanity(username) {
+		log.Printf("Join blocked - inappropriate display name: %s", username)
+		h.rejectProfanity(w, r, moderationService.StrikeDisplayName, "display name contains inappropriate content")
+		return
+	}
+
+	clientIP := util.ClientIP(r)
+	accessUserID := uuid.Nil
+	if authenticated {
+		accessUserID, _ = uuid.Parse(clientID)
+	}
+	if err := h.moderationService.CheckAccess(accessUserID, clientIP); err != nil {
+		util.WriteError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	// Platform moderators and admins aren't held to room limits
+	moderator := false
+	if authenticated {
+		user, err := h.userRepo.GetUserById(ctx, accessUserID)
+		if err != nil {
+			// Users deleted since their token was issued end up here too
+			util.WriteError(w, http.StatusForbidden, "account not found")
+			return
+		}
+		moderator = user.Role == userRepo.RoleModerator || user.Role == userRepo.RoleAdmin
+	}
+
+	// Archived rooms are read-only and only reachable by their participants
+	if dbRoom.IsArchived() {
+		allowed := false
+		if authenticated {
+			allowed, err = h.isRoomParticipant(ctx, dbRoom, accessUserID)
+			if err != nil {
+				log.Printf("Error checking participant for archived room %s: %v", roomID, err)
+				util.WriteError(w, http.StatusInternalServerError, "failed to check permissions")
+				return
+			}
+		}
+		if !allowed {
+			util.WriteError(w, http.StatusForbidden, "room is archived")
+			return
+		}
+	}
+
 	// Ensure room exists in memory map
-	if _, exists := h.core.Rooms[roomID]; !exists {
-		h.core.Rooms[roomID] = &ws.Room{
-			ID:               roomID,
-			Name:             dbRoom.Name,
-			Clients:          make(map[string]*ws.Client),
-			IsPinned:         dbRoom.IsPinned,
-			TopicTitle:       dbRoom.TopicTitle,
-			TopicDescription: dbRoom.TopicDescription,
-			TopicURL:         dbRoom.TopicURL,
-			TopicSource:      dbRoom.TopicSource,
+	if err := h.core.AddRooms(ctx, newMemoryRoom(dbRoom)); err != nil {
+		util.WriteError(w, http.StatusServiceUnavailable, "failed to load room")
+		return
+	}
+	if dbRoom.IsArchived() {
+		if err := h.core.ArchiveRooms(ctx, roomID); err != nil {
+			util.WriteError(w, http.StatusServiceUnavailable, "failed to load room")
+			return
 		}
 	}
 
@@ -180,6 +380,7 @@ func (h *CoreHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 			return true
 		},
 		EnableCompression: true,
+		Subprotocols:      ws.Subprotocols,
 	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -188,16 +389,17 @@ func (h *CoreHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	q := r.URL.Query()
-	clientID := q.Get("userId")
-	username := q.Get("username")
-
 	cl := &ws.Client{
-		Conn:     conn,
-		Message:  make(chan *ws.Message, 10),
-		ID:       clientID,
-		RoomID:   roomID,
-		Username: username,
+		Conn:          conn,
+		Message:       make(chan *ws.Message, 10),
+		ID:            clientID,
+		RoomID:        roomID,
+		Username:      username,
+		ResumeAfter:   resumeAfter,
+		Codec:         ws.CodecFor(conn.Subprotocol()),
+		IP:            clientIP,
+		Authenticated: authenticated,
+		Moderator:     moderator,
 	}
 
 	h.core.Register <- cl
@@ -206,20 +408,76 @@ func (h *CoreHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
 	cl.ReadMessage(h.core)
 }
 
+const (
+	defaultRoomPageSize = 50
+	maxRoomPageSize     = 100
+)
+
+// GetRooms lists active rooms. The listing can be filtered with category, tag
+// and q (name search), ordered with sort=active|trending|new and paged with
+// limit and cursor. The total number of matching rooms and the next page's
+// cursor are returned in the X-Total-Count and X-Next-Cursor headers.
 func (h *CoreHandler) GetRooms(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	q := r.URL.Query()
 
-	// Fetch active rooms from database
-	dbRooms, err := h.roomRepo.GetAllActiveRooms(ctx)
+	filter := roomRepo.RoomFilter{
+		Category: strings.ToLower(strings.TrimSpace(q.Get("category"))),
+		Tag:      normalizeTag(q.Get("tag")),
+		Search:   strings.TrimSpace(q.Get("q")),
+	}
+	if filter.Category != "" && !model.IsRoomCategory(filter.Category) {
+		util.WriteError(w, http.StatusBadRequest, "unknown category")
+		return
+	}
+
+	limit := defaultRoomPageSize
+	if limitStr := q.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			util.WriteError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(parsed, maxRoomPageSize)
+	}
+
+	var page roomPage
+	var err error
+	switch sortBy := q.Get("sort"); sortBy {
+	case "":
+		page, err = h.listRoomsByKeyset(ctx, filter, roomRepo.RoomSortPinnedFirst, q.Get("cursor"), limit)
+	case "new":
+		page, err = h.listRoomsByKeyset(ctx, filter, roomRepo.RoomSortNewest, q.Get("cursor"), limit)
+	case "active", "trending":
+		page, err = h.listRoomsByActivity(ctx, filter, sortBy, q.Get("cursor"), limit)
+	default:
+		util.WriteError(w, http.StatusBadRequest, "invalid sort, use active, trending or new")
+		return
+	}
 	if err != nil {
+		if errors.Is(err, roomRepo.ErrInvalidCursor) {
+			util.WriteError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		log.Printf("Error listing rooms: %v", err)
 		util.WriteError(w, http.StatusInternalServerError, "failed to fetch rooms")
 		return
 	}
 
-	rooms := make([]model.RoomRes, 0, len(dbRooms))
-	for _, room := range dbRooms {
+	connected, err := h.core.ClientCounts(ctx)
+	if err != nil {
+		util.WriteError(w, http.StatusServiceUnavailable, "failed to fetch rooms")
+		return
+	}
+
+	rooms := make([]model.RoomRes, 0, len(page.rooms))
+	live := make([]*ws.Room, 0, len(page.rooms))
+	for _, room := range page.rooms {
+		id := room.ID.String()
+		activity := page.activity[room.ID]
+
 		rooms = append(rooms, model.RoomRes{
-			ID:               room.ID.String(),
+			ID:               id,
 			Name:             room.Name,
 			IsPinned:         room.IsPinned,
 			CreatedAt:        room.CreatedAt,
@@ -228,37 +486,184 @@ func (h *CoreHandler) GetRooms(w http.ResponseWriter, r *http.Request) {
 			TopicDescription: room.TopicDescription,
 			TopicURL:         room.TopicURL,
 			TopicSource:      room.TopicSource,
+			Category:         room.Category,
+			Tags:             room.Tags,
+			Description:      room.Description,
+			Rules:            room.Rules,
+			SlowModeSeconds:  room.SlowModeSeconds,
+			ConnectedUsers:   connected[id],
+			MessagesLastHour: activity.MessagesLastHour,
+			LastActivityAt:   activity.LastActivityAt,
 		})
+		live = append(live, newMemoryRoom(room))
+	}
+
+	// Ensure rooms exist in memory map
+	if err := h.core.AddRooms(ctx, live...); err != nil {
+		log.Printf("Error loading listed rooms: %v", err)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.total))
+	if page.nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.nextCursor)
+	}
+	util.WriteJSON(w, http.StatusOK, rooms)
+}
+
+type roomPage struct {
+	rooms      []*roomRepo.Room
+	activity   map[uuid.UUID]roomRepo.RoomActivity
+	total      int
+	nextCursor string
+}
+
+// listRoomsByKeyset pages through rooms in a stable database order
+func (h *CoreHandler) listRoomsByKeyset(ctx context.Context, filter roomRepo.RoomFilter, order roomRepo.RoomSort, cursor string, limit int) (roomPage, error) {
+	var page roomPage
+
+	var after *roomRepo.RoomCursor
+	if cursor != "" {
+		var err error
+		if after, err = roomRepo.DecodeRoomCursor(cursor); err != nil {
+			return page, err
+		}
+	}
+
+	// Fetch active rooms from database
+	rooms, next, err := h.roomRepo.ListActiveRooms(ctx, filter, order, after, limit)
+	if err != nil {
+		return page, err
+	}
+
+	total, err := h.roomRepo.CountFilteredRooms(ctx, filter)
+	if err != nil {
+		return page, err
+	}
+
+	ids := make([]uuid.UUID, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+	}
+	activity, err := h.roomRepo.GetRoomActivity(ctx, ids)
+	if err != nil {
+		return page, err
+	}
+
+	page = roomPage{rooms: rooms, activity: activity, total: total}
+	if next != nil {
+		page.nextCursor = next.Encode()
+	}
+	return page, nil
+}
+
+// listRoomsByActivity ranks every matching room by live activity. Rankings
+// shift between requests, so pages are addressed by offset rather than keyset.
+//
+//   - active: most connected users first, then most recently active
+//   - trending: highest message velocity over the last 15 minutes, then the last hour
+func (h *CoreHandler) listRoomsByActivity(ctx context.Context, filter roomRepo.RoomFilter, sortBy, cursor string, limit int) (roomPage, error) {
+	var page roomPage
+
+	offset := 0
+	if cursor != "" {
+		var err error
+		if offset, err = decodeOffsetCursor(cursor); err != nil {
+			return page, err
+		}
+	}
+
+	rooms, err := h.roomRepo.FilterActiveRooms(ctx, filter)
+	if err != nil {
+		return page, err
+	}
+
+	counts, err := h.core.ClientCounts(ctx)
+	if err != nil {
+		return page, err
+	}
+
+	ids := make([]uuid.UUID, len(rooms))
+	connected := make(map[uuid.UUID]int, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+		connected[room.ID] = counts[room.ID.String()]
+	}
+	activity, err := h.roomRepo.GetRoomActivity(ctx, ids)
+	if err != nil {
+		return page, err
+	}
 
-		// Ensure room exists in memory map
-		if _, exists := h.core.Rooms[room.ID.String()]; !exists {
-			h.core.Rooms[room.ID.String()] = &ws.Room{
-				ID:               room.ID.String(),
-				Name:             room.Name,
-				Clients:          make(map[string]*ws.Client),
-				IsPinned:         room.IsPinned,
-				TopicTitle:       room.TopicTitle,
-				TopicDescription: room.TopicDescription,
-				TopicURL:         room.TopicURL,
-				TopicSource:      room.TopicSource,
+	lastActive := func(room *roomRepo.Room) time.Time {
+		if a, ok := activity[room.ID]; ok && a.LastActivityAt != nil {
+			return *a.LastActivityAt
+		}
+		return room.CreatedAt
+	}
+
+	// Rooms come back newest first, so a stable sort keeps that as the final tie-breaker
+	sort.SliceStable(rooms, func(i, j int) bool {
+		a, b := rooms[i], rooms[j]
+		if sortBy == "trending" {
+			if va, vb := activity[a.ID].MessagesLast15Min, activity[b.ID].MessagesLast15Min; va != vb {
+				return va > vb
 			}
+			if va, vb := activity[a.ID].MessagesLastHour, activity[b.ID].MessagesLastHour; va != vb {
+				return va > vb
+			}
+			return false
+		}
+
+		if connected[a.ID] != connected[b.ID] {
+			return connected[a.ID] > connected[b.ID]
+		}
+		return lastActive(a).After(lastActive(b))
+	})
+
+	page = roomPage{activity: activity, total: len(rooms)}
+	if offset < len(rooms) {
+		end := min(offset+limit, len(rooms))
+		page.rooms = rooms[offset:end]
+		if end < len(rooms) {
+			page.nextCursor = encodeOffsetCursor(end)
 		}
 	}
+	return page, nil
+}
 
-	util.WriteJSON(w, http.StatusOK, rooms)
+func encodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset|" + strconv.Itoa(offset)))
+}
+
+func decodeOffsetCursor(s string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, roomRepo.ErrInvalidCursor
+	}
+
+	offsetStr, ok := strings.CutPrefix(string(raw), "offset|")
+	if !ok {
+		return 0, roomRepo.ErrInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return 0, roomRepo.ErrInvalidCursor
+	}
+
+	return offset, nil
 }
 
 func (h *CoreHandler) GetClients(w http.ResponseWriter, r *http.Request) {
-	var clients []model.ClientRes
 	roomID := chi.URLParam(r, "roomId") // from /ws/{roomId}
 
-	if _, ok := h.core.Rooms[roomID]; !ok {
-		clients = make([]model.ClientRes, 0)
-		util.WriteJSON(w, http.StatusOK, clients)
+	connected, err := h.core.RoomClients(r.Context(), roomID)
+	if err != nil {
+		util.WriteError(w, http.StatusServiceUnavailable, "failed to fetch clients")
 		return
 	}
 
-	for _, c := range h.core.Rooms[roomID].Clients {
+	clients := make([]model.ClientRes, 0, len(connected))
+	for _, c := range connected {
 		clients = append(clients, model.ClientRes{
 			ID:       c.ID,
 			Username: c.Username,
@@ -267,3 +672,440 @@ func (h *CoreHandler) GetClients(w http.ResponseWriter, r *http.Request) {
 
 	util.WriteJSON(w, http.StatusOK, clients)
 }
+
+// GetMetrics reports the message persistence queue so operators can spot a
+// database that can't keep up
+func (h *CoreHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	util.WriteJSON(w, http.StatusOK, map[string]any{
+		"message_writer": h.core.WriterStats(),
+	})
+}
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
+)
+
+// GetRoomMessages returns a page of a room's message history, newest page first
+func (h *CoreHandler) GetRoomMessages(w http.ResponseWriter, r *http.Request) {
+	roomUUID, err := uuid.Parse(chi.URLParam(r, "roomId"))
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid room ID")
+		return
+	}
+
+	q := r.URL.Query()
+
+	limit := defaultHistoryLimit
+	if limitStr := q.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			util.WriteError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(parsed, maxHistoryLimit)
+	}
+
+	var before *roomRepo.MessageCursor
+	if cursor := q.Get("before"); cursor != "" {
+		before, err = roomRepo.DecodeMessageCursor(cursor)
+		if err != nil {
+			util.WriteError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	dbRoom, err := h.roomRepo.GetRoomByIDIncludingArchived(ctx, roomUUID)
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, "failed to verify room")
+		return
+	}
+	if dbRoom == nil {
+		util.WriteError(w, http.StatusNotFound, "room not found or expired")
+		return
+	}
+
+	// History of archived rooms stays reachable to participants only
+	if dbRoom.IsArchived() {
+		allowed := false
+		if userIDStr, ok := ctx.Value("userID").(string); ok {
+			if uid, err := uuid.Parse(userIDStr); err == nil {
+				allowed, err = h.isRoomParticipant(ctx, dbRoom, uid)
+				if err != nil {
+					log.Printf("Error checking participant for archived room %s: %v", roomUUID.String(), err)
+					util.WriteError(w, http.StatusInternalServerError, "failed to check permissions")
+					return
+				}
+			}
+		}
+		if !allowed {
+			util.WriteError(w, http.StatusForbidden, "room is archived")
+			return
+		}
+	}
+
+	messages, hasMore, err := h.messageRepo.GetRoomMessagesBefore(ctx, roomUUID, before, limit)
+	if err != nil {
+		log.Printf("Error fetching messages for room %s: %v", roomUUID.String(), err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to fetch messages")
+		return
+	}
+
+	resp := model.MessageHistoryRes{
+		Messages: make([]model.MessageRes, 0, len(messages)),
+		HasMore:  hasMore,
+	}
+	for _, msg := range messages {
+		userID := ""
+		if msg.UserID != nil {
+			userID = msg.UserID.String()
+		}
+
+		resp.Messages = append(resp.Messages, model.MessageRes{
+			ID:        msg.ID.String(),
+			RoomID:    msg.RoomID.String(),
+			UserID:    userID,
+			Username:  msg.Username,
+			Content:   msg.Content,
+			System:    msg.IsSystem,
+			Timestamp: msg.CreatedAt,
+			Seq:       msg.Seq,
+		})
+	}
+
+	// The oldest message of this page is where the next page starts
+	if hasMore && len(messages) > 0 {
+		resp.NextCursor = roomRepo.MessageCursor{Seq: messages[0].Seq}.Encode()
+	}
+
+	util.WriteJSON(w, http.StatusOK, resp)
+}
+
+// ExportRoom streams the full room transcript to the room creator or a participant
+func (h *CoreHandler) ExportRoom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userIDStr, ok := ctx.Value("userID").(string)
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	roomID := chi.URLParam(r, "roomId")
+	roomUUID, err := uuid.Parse(roomID)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid room ID")
+		return
+	}
+
+	format, ok := transcript.ParseFormat(r.URL.Query().Get("format"))
+	if !ok {
+		util.WriteError(w, http.StatusBadRequest, "unsupported format, use json, ndjson, markdown or html")
+		return
+	}
+
+	dbRoom, err := h.roomRepo.GetRoomByIDIncludingArchived(ctx, roomUUID)
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, "failed to verify room")
+		return
+	}
+	if dbRoom == nil {
+		util.WriteError(w, http.StatusNotFound, "room not found or expired")
+		return
+	}
+
+	allowed, err := h.isRoomParticipant(ctx, dbRoom, userID)
+	if err != nil {
+		log.Printf("Error checking export permission for room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to check permissions")
+		return
+	}
+	if !allowed {
+		util.WriteError(w, http.StatusForbidden, "only the room creator or participants can export this room")
+		return
+	}
+
+	filename := fmt.Sprintf("room-%s.%s", roomID, format.Extension())
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so failures past this point can only be logged
+	if err := h.transcriptService.Export(ctx, dbRoom, format, w); err != nil {
+		log.Printf("Error exporting room %s: %v", roomID, err)
+	}
+}
+
+// isRoomParticipant reports whether the user created the room, posted in it or is currently connected to it
+func (h *CoreHandler) isRoomParticipant(ctx context.Context, room *roomRepo.Room, userID uuid.UUID) (bool, error) {
+	if room.CreatorID != nil && *room.CreatorID == userID {
+		return true, nil
+	}
+
+	connected, err := h.core.IsConnected(ctx, room.ID.String(), userID.String())
+	if err != nil {
+		return false, err
+	}
+	if connected {
+		return true, nil
+	}
+
+	return h.messageRepo.IsRoomParticipant(ctx, room.ID, userID)
+}
+
+// ExtendRoom lets the room creator push back the room's expiry
+func (h *CoreHandler) ExtendRoom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value("userID").(string)
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	roomID := chi.URLParam(r, "roomId")
+	roomUUID, err := uuid.Parse(roomID)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid room ID")
+		return
+	}
+
+	// Make sure the core knows about the room before asking it to extend
+	dbRoom, err := h.roomRepo.GetRoomByID(ctx, roomUUID)
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, "failed to verify room")
+		return
+	}
+	if dbRoom == nil {
+		util.WriteError(w, http.StatusNotFound, "room not found or expired")
+		return
+	}
+	if err := h.core.AddRooms(ctx, newMemoryRoom(dbRoom)); err != nil {
+		util.WriteError(w, http.StatusServiceUnavailable, "failed to load room")
+		return
+	}
+
+	expiresAt, err := h.core.ExtendRoom(ctx, roomID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ws.ErrRoomNotFound):
+			util.WriteError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, ws.ErrNotRoomCreator):
+			util.WriteError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, ws.ErrPinnedRoomExtension),
+			errors.Is(err, ws.ErrLifetimeCapReached),
+			errors.Is(err, ws.ErrExtensionInProgress):
+			util.WriteError(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("Error extending room %s: %v", roomID, err)
+			util.WriteError(w, http.StatusInternalServerError, "failed to extend room")
+		}
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, map[string]any{
+		"id":         roomID,
+		"expires_at": expiresAt,
+	})
+}
+
+const (
+	maxRoomNameLen        = 100
+	maxRoomDescriptionLen = 500
+	maxRoomRulesLen       = 2000
+	maxSlowModeSeconds    = 3600
+)
+
+// UpdateRoom lets the room creator edit the room's settings
+func (h *CoreHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userIDStr, ok := ctx.Value("userID").(string)
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	roomID := chi.URLParam(r, "roomId")
+	roomUUID, err := uuid.Parse(roomID)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid room ID")
+		return
+	}
+
+	var req model.UpdateRoomReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	if msg, strikeSource := h.validateRoomUpdate(&req); strikeSource != "" {
+		h.rejectProfanity(w, r, strikeSource, msg)
+		return
+	} else if msg != "" {
+		util.WriteError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	dbRoom, err := h.roomRepo.GetRoomByID(ctx, roomUUID)
+	if err != nil {
+		util.WriteError(w, http.StatusInternalServerError, "failed to verify room")
+		return
+	}
+	if dbRoom == nil {
+		util.WriteError(w, http.StatusNotFound, "room not found or expired")
+		return
+	}
+	if dbRoom.CreatorID == nil || *dbRoom.CreatorID != userID {
+		util.WriteError(w, http.StatusForbidden, "only the room creator can edit the room")
+		return
+	}
+
+	updated, err := h.roomRepo.UpdateRoomSettings(ctx, roomUUID, roomRepo.RoomSettings{
+		Name:             req.Name,
+		Description:      req.Description,
+		Rules:            req.Rules,
+		TopicTitle:       req.TopicTitle,
+		TopicDescription: req.TopicDescription,
+		TopicURL:         req.TopicURL,
+		SlowModeSeconds:  req.SlowModeSeconds,
+	})
+	if err != nil {
+		log.Printf("Error updating room %s: %v", roomID, err)
+		util.WriteError(w, http.StatusInternalServerError, "failed to update room")
+		return
+	}
+	if updated == nil {
+		util.WriteError(w, http.StatusNotFound, "room not found or expired")
+		return
+	}
+
+	if err := h.core.AddRooms(ctx, newMemoryRoom(updated)); err != nil {
+		log.Printf("Error loading updated room %s: %v", roomID, err)
+	}
+	h.core.UpdateRoomSettings(ws.RoomSettings{
+		RoomID:           roomID,
+		Name:             updated.Name,
+		Description:      updated.Description,
+		Rules:            updated.Rules,
+		TopicTitle:       updated.TopicTitle,
+		TopicDescription: updated.TopicDescription,
+		TopicURL:         updated.TopicURL,
+		SlowModeSeconds:  updated.SlowModeSeconds,
+		Changed:          changedRoomSettings(dbRoom, updated),
+	})
+
+	connected, err := h.core.ClientCounts(ctx)
+	if err != nil {
+		log.Printf("Error counting clients of room %s: %v", roomID, err)
+	}
+
+	util.WriteJSON(w, http.StatusOK, model.RoomRes{
+		ID:               roomID,
+		Name:             updated.Name,
+		IsPinned:         updated.IsPinned,
+		CreatedAt:        updated.CreatedAt,
+		ExpiresAt:        updated.ExpiresAt,
+		TopicTitle:       updated.TopicTitle,
+		TopicDescription: updated.TopicDescription,
+		TopicURL:         updated.TopicURL,
+		TopicSource:      updated.TopicSource,
+		Category:         updated.Category,
+		Tags:             updated.Tags,
+		Description:      updated.Description,
+		Rules:            updated.Rules,
+		SlowModeSeconds:  updated.SlowModeSeconds,
+		ConnectedUsers:   connected[roomID],
+	})
+}
+
+// validateRoomUpdate trims the requested settings in place and returns a
+// message describing the first invalid field, or "" if the update is valid.
+// If the field was rejected by the profanity filter, strikeSource says
+// where the strike came from.
+func (h *CoreHandler) validateRoomUpdate(req *model.UpdateRoomReq) (msg, strikeSource string) {
+	texts := []struct {
+		field  string
+		value  *string
+		maxLen int
+		source string
+	}{
+		{"name", req.Name, maxRoomNameLen, moderationService.StrikeRoomName},
+		{"description", req.Description, maxRoomDescriptionLen, moderationService.StrikeRoomDetails},
+		{"rules", req.Rules, maxRoomRulesLen, moderationService.StrikeRoomDetails},
+		{"topic title", req.TopicTitle, maxRoomNameLen, moderationService.StrikeRoomTopic},
+		{"topic description", req.TopicDescription, maxRoomDescriptionLen, moderationService.StrikeRoomTopic},
+		{"topic URL", req.TopicURL, maxRoomDescriptionLen, moderationService.StrikeRoomTopic},
+	}
+
+	for _, t := range texts {
+		if t.value == nil {
+			continue
+		}
+		*t.value = strings.TrimSpace(*t.value)
+		if len(*t.value) > t.maxLen {
+			return fmt.Sprintf("%s must be at most %d characters", t.field, t.maxLen), ""
+		}
+		if h.profanityFilter.ContainsProfanity(*t.value) {
+			log.Printf("Room update blocked - inappropriate %s: %s", t.field, *t.value)
+			return fmt.Sprintf("room %s contains inappropriate content", t.field), t.source
+		}
+	}
+
+	if req.Name != nil && *req.Name == "" {
+		return "name can't be empty", ""
+	}
+	if req.TopicURL != nil && *req.TopicURL != "" {
+		u, err := url.Parse(*req.TopicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "topic URL must be an http or https URL", ""
+		}
+	}
+	if req.SlowModeSeconds != nil && (*req.SlowModeSeconds < 0 || *req.SlowModeSeconds > maxSlowModeSeconds) {
+		return fmt.Sprintf("slow mode must be between 0 and %d seconds", maxSlowModeSeconds), ""
+	}
+
+	return "", ""
+}
+
+// changedRoomSettings names the settings that differ between two versions of a room
+func changedRoomSettings(before, after *roomRepo.Room) []string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	var changed []string
+	if before.Name != after.Name {
+		changed = append(changed, "name")
+	}
+	if deref(before.Description) != deref(after.Description) {
+		changed = append(changed, "description")
+	}
+	if deref(before.Rules) != deref(after.Rules) {
+		changed = append(changed, "rules")
+	}
+	if deref(before.TopicTitle) != deref(after.TopicTitle) ||
+		deref(before.TopicDescription) != deref(after.TopicDescription) ||
+		deref(before.TopicURL) != deref(after.TopicURL) {
+		changed = append(changed, "topic")
+	}
+	if before.SlowModeSeconds != after.SlowModeSeconds {
+		changed = append(changed, "slow mode")
+	}
+
+	return changed
+}
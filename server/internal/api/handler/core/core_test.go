@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/internal/api/model"
+	"github.com/momomo0206/go-chat-app/internal/filter"
+	"github.com/momomo0206/go-chat-app/internal/repo/memory"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	auditService "github.com/momomo0206/go-chat-app/internal/service/audit"
+	moderationService "github.com/momomo0206/go-chat-app/internal/service/moderation"
+	"github.com/momomo0206/go-chat-app/internal/ws"
+)
+
+type testServer struct {
+	router http.Handler
+	core   *ws.Core
+	users  *memory.UserRepository
+	rooms  *memory.RoomRepository
+}
+
+// newTestServer wires the core handler to the in-memory stores, routed like
+// the real server. The core runs until the test ends.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	users := memory.NewUserRepository()
+	rooms := memory.NewRoomRepository()
+	core := ws.NewCore(rooms, rooms, memory.NewStatsRepository())
+	go core.Run()
+	t.Cleanup(core.Stop)
+
+	audit := auditService.NewAuditService(memory.NewAuditRepository())
+	moderation := moderationService.NewModerationService(memory.NewModerationRepository(), users, rooms, rooms, core, audit)
+	profanity, err := filter.NewProfanityFilter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewCoreHandler(core, users, rooms, rooms, moderation, profanity)
+	r := chi.NewRouter()
+	r.Post("/ws/createRoom", h.CreateRoom)
+	r.Get("/ws/joinRoom/{roomId}", h.JoinRoom)
+	r.Get("/ws/getRooms", h.GetRooms)
+
+	return &testServer{router: r, core: core, users: users, rooms: rooms}
+}
+
+// do serves the request, signed in as userID unless it is empty
+func (s *testServer) do(method, target, body, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if userID != "" {
+		req = req.WithContext(context.WithValue(req.Context(), "userID", userID))
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *testServer) createUser(t *testing.T) string {
+	t.Helper()
+
+	user, err := s.users.CreateUser(context.Background(), &userRepo.User{Username: "alice", Email: "alice@example.com", Role: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.ID.String()
+}
+
+func TestCreateRoom(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		signedIn   bool
+		hasRoom    bool
+		wantStatus int
+	}{
+		{name: "invalid JSON", body: "{", wantStatus: http.StatusBadRequest},
+		{name: "unknown category", body: `{"name":"lobby","category":"knitting"}`, wantStatus: http.StatusBadRequest},
+		{name: "profane name", body: `{"name":"fuck this"}`, wantStatus: http.StatusBadRequest},
+		{name: "guest", body: `{"name":"lobby","category":"Tech"}`, wantStatus: http.StatusOK},
+		{name: "signed in", body: `{"name":"lobby","tags":["go"]}`, signedIn: true, wantStatus: http.StatusOK},
+		{name: "signed in with an active room", body: `{"name":"lobby"}`, signedIn: true, hasRoom: true, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			ctx := context.Background()
+
+			var userID string
+			if tt.signedIn {
+				userID = uuid.NewString()
+			}
+			if tt.hasRoom {
+				creatorID := uuid.MustParse(userID)
+				if _, err := s.rooms.CreateRoom(ctx, &roomRepo.Room{Name: "existing", CreatorID: &creatorID}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rec := s.do(http.MethodPost, "/ws/createRoom", tt.body, userID)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var created model.CreateRoomReq
+			if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+				t.Fatal(err)
+			}
+
+			// The new room is live straight away and listed
+			counts, err := s.core.ClientCounts(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := counts[created.ID]; !ok {
+				t.Errorf("room %s wasn't loaded into the core", created.ID)
+			}
+
+			rec = s.do(http.MethodGet, "/ws/getRooms", "", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("getRooms got status %d: %s", rec.Code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), created.ID) {
+				t.Errorf("getRooms didn't list room %s: %s", created.ID, rec.Body)
+			}
+		})
+	}
+}
+
+// upgradeRefused is the error JoinRoom returns once a request has passed its
+// checks but isn't a websocket handshake, as is the case for every request here
+const upgradeRefused = "invalid connection upgrade"
+
+func TestJoinRoom(t *testing.T) {
+	tests := []struct {
+		name       string
+		roomID     string
+		query      string
+		signedIn   bool
+		deleted    bool
+		wantStatus int
+		// wantJoin means the request passes every check up to the upgrade
+		wantJoin bool
+	}{
+		{name: "invalid room ID", roomID: "nope", wantStatus: http.StatusBadRequest},
+		{name: "unknown room", roomID: uuid.NewString(), wantStatus: http.StatusNotFound},
+		{name: "invalid since", query: "?since=-1", wantStatus: http.StatusBadRequest},
+		{name: "user claiming another user ID", query: "?userId=" + uuid.NewString(), signedIn: true, wantStatus: http.StatusForbidden},
+		{name: "deleted account", signedIn: true, deleted: true, wantStatus: http.StatusForbidden},
+		{name: "guest", wantStatus: http.StatusBadRequest, wantJoin: true},
+		{name: "guest sending a userId", query: "?userId=anonymousUser_abc123", wantStatus: http.StatusBadRequest, wantJoin: true},
+		{name: "user sending their own userId", query: "?userId={user}", signedIn: true, wantStatus: http.StatusBadRequest, wantJoin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+
+			room, err := s.rooms.CreateRoom(context.Background(), &roomRepo.Room{Name: "lobby"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			roomID := room.ID.String()
+			if tt.roomID != "" {
+				roomID = tt.roomID
+			}
+
+			var userID string
+			if tt.signedIn {
+				userID = s.createUser(t)
+			}
+			if tt.deleted {
+				if err := s.users.DeleteUser(context.Background(), uuid.MustParse(userID)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			query := strings.ReplaceAll(tt.query, "{user}", userID)
+			rec := s.do(http.MethodGet, "/ws/joinRoom/"+roomID+query, "", userID)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if joined := strings.Contains(rec.Body.String(), upgradeRefused); joined != tt.wantJoin {
+				t.Errorf("got past the checks = %v, want %v: %s", joined, tt.wantJoin, rec.Body)
+			}
+		})
+	}
+}
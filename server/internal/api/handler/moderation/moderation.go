@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/internal/api/model"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+	moderationService "github.com/momomo0206/go-chat-app/internal/service/moderation"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+const (
+	defaultQueuePageSize = 50
+	maxQueuePageSize     = 200
+)
+
+type ModerationHandler struct {
+	moderationService *moderationService.ModerationService
+}
+
+func NewModerationHandler(moderationService *moderationService.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+	}
+}
+
+// currentUserID returns the authenticated user from the JWT middleware
+func currentUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userIDStr, ok := r.Context().Value("userID").(string)
+	if !ok {
+		util.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid user ID")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// writeServiceError maps moderation service errors to responses
+func writeServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, moderationService.ErrInvalidReport),
+		errors.Is(err, moderationService.ErrInvalidAction),
+		errors.Is(err, moderationService.ErrInvalidSanction),
+		errors.Is(err, moderationService.ErrReportSelf):
+		util.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, moderationService.ErrReportTargetNotFound),
+		errors.Is(err, moderationService.ErrReportNotFound),
+		errors.Is(err, moderationService.ErrUserNotFound),
+		errors.Is(err, moderationService.ErrSanctionNotFound),
+		errors.Is(err, moderationService.ErrIPBanNotFound):
+		util.WriteError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, moderationService.ErrReportResolved):
+		util.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, moderationService.ErrProtectedUser),
+		errors.Is(err, moderationService.ErrBanOwnAddress):
+		util.WriteError(w, http.StatusForbidden, err.Error())
+	default:
+		log.Printf("Moderation error: %v", err)
+		util.WriteError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// CreateReport files a report about a message, user or room
+func (h *ModerationHandler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	reporterID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateReportReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	report, err := h.moderationService.CreateReport(r.Context(), reporterID, req)
+	if err != nil {
+		writeServiceError(w, err, "failed to create report")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusCreated, report)
+}
+
+// GetMyReports lists the reports the current user filed and their outcome
+func (h *ModerationHandler) GetMyReports(w http.ResponseWriter, r *http.Request) {
+	reporterID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	reports, err := h.moderationService.MyReports(r.Context(), reporterID)
+	if err != nil {
+		writeServiceError(w, err, "failed to get reports")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, reports)
+}
+
+// ListReports returns the moderation queue, oldest first. It shows open
+// reports unless ?status= asks for dismissed, actioned or all.
+func (h *ModerationHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := moderationRepo.ReportFilter{
+		Status:     q.Get("status"),
+		TargetType: q.Get("target_type"),
+		Limit:      defaultQueuePageSize,
+	}
+
+	switch filter.Status {
+	case "":
+		filter.Status = moderationRepo.StatusOpen
+	case "all":
+		filter.Status = ""
+	case moderationRepo.StatusOpen, moderationRepo.StatusDismissed, moderationRepo.StatusActioned:
+	default:
+		util.WriteError(w, http.StatusBadRequest, "status must be open, dismissed, actioned or all")
+		return
+	}
+
+	switch filter.TargetType {
+	case "", moderationRepo.TargetMessage, moderationRepo.TargetUser, moderationRepo.TargetRoom:
+	default:
+		util.WriteError(w, http.StatusBadRequest, "target_type must be message, user or room")
+		return
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			util.WriteError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		filter.Limit = min(limit, maxQueuePageSize)
+	}
+
+	if raw := q.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			util.WriteError(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		filter.Offset = offset
+	}
+
+	reports, total, err := h.moderationService.ListReports(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, err, "failed to list reports")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, model.ReportsResponse{
+		Reports: reports,
+		Total:   total,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	})
+}
+
+func (h *ModerationHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(chi.URLParam(r, "reportId"))
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid report ID")
+		return
+	}
+
+	report, err := h.moderationService.GetReport(r.Context(), reportID)
+	if err != nil {
+		writeServiceError(w, err, "failed to get report")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, report)
+}
+
+// ResolveReport dismisses a report or acts on it by deleting the message,
+// muting, banning or shadow-banning the reported user
+func (h *ModerationHandler) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(chi.URLParam(r, "reportId"))
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid report ID")
+		return
+	}
+
+	var req model.ResolveReportReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	report, err := h.moderationService.ResolveReport(r.Context(), moderatorID, reportID, req)
+	if err != nil {
+		writeServiceError(w, err, "failed to resolve report")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, report)
+}
+
+// ListSanctions returns the active sanctions, optionally of one ?kind=
+func (h *ModerationHandler) ListSanctions(w http.ResponseWriter, r *http.Request) {
+	sanctions, err := h.moderationService.ListSanctions(r.Context(), r.URL.Query().Get("kind"))
+	if err != nil {
+		writeServiceError(w, err, "failed to list sanctions")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, sanctions)
+}
+
+// CreateSanction mutes, bans or shadow-bans a user without a report
+func (h *ModerationHandler) CreateSanction(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateSanctionReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	sanction, err := h.moderationService.CreateSanction(r.Context(), moderatorID, req)
+	if err != nil {
+		writeServiceError(w, err, "failed to create sanction")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusCreated, sanction)
+}
+
+// RevokeSanction lifts a sanction before it expires
+func (h *ModerationHandler) RevokeSanction(w http.ResponseWriter, r *http.Request) {
+	moderatorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	sanctionID, err := uuid.Parse(chi.URLParam(r, "sanctionId"))
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid sanction ID")
+		return
+	}
+
+	sanction, err := h.moderationService.RevokeSanction(r.Context(), moderatorID, sanctionID)
+	if err != nil {
+		writeServiceError(w, err, "failed to revoke sanction")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, sanction)
+}
+
+func (h *ModerationHandler) ListIPBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := h.moderationService.ListIPBans(r.Context())
+	if err != nil {
+		writeServiceError(w, err, "failed to list ip bans")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusOK, bans)
+}
+
+// CreateIPBan bans an address or network and disconnects its clients
+func (h *ModerationHandler) CreateIPBan(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateIPBanReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	ban, err := h.moderationService.CreateIPBan(r.Context(), adminID, util.ClientIP(r), req)
+	if err != nil {
+		writeServiceError(w, err, "failed to create ip ban")
+		return
+	}
+
+	util.WriteJSON(w, http.StatusCreated, ban)
+}
+
+func (h *ModerationHandler) RevokeIPBan(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	banID, err := uuid.Parse(chi.URLParam(r, "banId"))
+	if err != nil {
+		util.WriteError(w, http.StatusBadRequest, "invalid ip ban ID")
+		return
+	}
+
+	if err := h.moderationService.RevokeIPBan(r.Context(), adminID, banID); err != nil {
+		writeServiceError(w, err, "failed to revoke ip ban")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/internal/repo/memory"
+	statsService "github.com/momomo0206/go-chat-app/internal/service/stats"
+)
+
+func TestCheckIn(t *testing.T) {
+	h := NewStatsHandler(statsService.NewStatsService(memory.NewStatsRepository()))
+	userID := uuid.NewString()
+
+	// The cases run in order against the same store
+	tests := []struct {
+		name       string
+		userID     string
+		wantStatus int
+		wantResult statsService.CheckinResult
+	}{
+		{name: "not signed in", wantStatus: http.StatusUnauthorized},
+		{name: "first check-in", userID: userID, wantStatus: http.StatusOK, wantResult: statsService.CheckinResult{StreakCount: 1, IsNewCheckin: true}},
+		{name: "again the same day", userID: userID, wantStatus: http.StatusOK, wantResult: statsService.CheckinResult{StreakCount: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/stats/checkin", nil)
+			if tt.userID != "" {
+				req = req.WithContext(context.WithValue(req.Context(), "userID", tt.userID))
+			}
+			rec := httptest.NewRecorder()
+			h.CheckIn(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var got statsService.CheckinResult
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.StreakCount != tt.wantResult.StreakCount || got.IsNewCheckin != tt.wantResult.IsNewCheckin {
+				t.Errorf("got streak %d, new %v; want streak %d, new %v", got.StreakCount, got.IsNewCheckin, tt.wantResult.StreakCount, tt.wantResult.IsNewCheckin)
+			}
+			if tt.wantResult.IsNewCheckin && len(got.NewAchievements) == 0 {
+				t.Error("first check-in didn't award an achievement")
+			}
+		})
+	}
+}
@@ -2,27 +2,44 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/momomo0206/go-chat-app/internal/api/model"
 	"github.com/momomo0206/go-chat-app/internal/filter"
+	moderationService "github.com/momomo0206/go-chat-app/internal/service/moderation"
 	service "github.com/momomo0206/go-chat-app/internal/service/user"
 	"github.com/momomo0206/go-chat-app/util"
 )
 
 type UserHandler struct {
-	userService     *service.UserService
-	ProfanityFilter *filter.ProfanityFilter
+	userService       *service.UserService
+	moderationService *moderationService.ModerationService
+	ProfanityFilter   *filter.ProfanityFilter
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
+func NewUserHandler(userService *service.UserService, moderation *moderationService.ModerationService, profanity *filter.ProfanityFilter) *UserHandler {
 	return &UserHandler{
-		userService:     userService,
-		ProfanityFilter: filter.NewProfanityFilter(),
+		userService:       userService,
+		moderationService: moderation,
+		ProfanityFilter:   profanity,
 	}
 }
 
+// rejectProfanity counts a username blocked by the profanity filter against
+// the user, or only their address on signup, and responds with msg and the
+// warning, mute or ban it led to
+func (h *UserHandler) rejectProfanity(w http.ResponseWriter, r *http.Request, userID uuid.UUID, msg string) {
+	strike := h.moderationService.RecordProfanityStrike(r.Context(), userID, util.ClientIP(r), moderationService.StrikeUsername)
+	util.WriteJSON(w, http.StatusBadRequest, map[string]any{
+		"error":  msg,
+		"notice": strike.Notice(),
+		"strike": strike,
+	})
+}
+
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req model.RequestCreateUser
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -36,14 +53,14 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	// Check for profanity in username
 	if h.ProfanityFilter.ContainsProfanity(req.Username) {
 		log.Printf("CreateUser - Username blocked for inappropriate content: %s", req.Username)
-		util.WriteError(w, http.StatusBadRequest, "username contains inappropriate content")
+		h.rejectProfanity(w, r, uuid.Nil, "username contains inappropriate content")
 		return
 	}
 
-	user, err := h.userService.CreateUser(r.Context(), req)
+	user, err := h.userService.CreateUser(r.Context(), req, util.ClientIP(r))
 	if err != nil {
 		log.Printf("CreateUser - Service error: %v", err)
-		util.WriteError(w, http.StatusInternalServerError, err.Error())
+		util.WriteError(w, serviceErrorStatus(err, http.StatusInternalServerError), err.Error())
 		return
 	}
 
@@ -70,9 +87,9 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.Login(r.Context(), req)
+	user, err := h.userService.Login(r.Context(), req, util.ClientIP(r))
 	if err != nil {
-		util.WriteError(w, http.StatusUnauthorized, err.Error())
+		util.WriteError(w, serviceErrorStatus(err, http.StatusUnauthorized), err.Error())
 		return
 	}
 
@@ -90,6 +107,15 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	util.WriteJSON(w, http.StatusOK, user)
 }
 
+// serviceErrorStatus returns 403 for banned users and addresses, fallback otherwise
+func serviceErrorStatus(err error, fallback int) int {
+	var banned *moderationService.BannedError
+	if errors.As(err, &banned) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
+
 func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "jwt",
@@ -129,7 +155,8 @@ func (h *UserHandler) UpdateUsername(w http.ResponseWriter, r *http.Request) {
 	// Check for profanity in username
 	if h.ProfanityFilter.ContainsProfanity(req.Username) {
 		log.Printf("UpdateUsername - Username blocked for inappropriate content: %s", req.Username)
-		util.WriteError(w, http.StatusBadRequest, "username contains inappropriate content")
+		uid, _ := uuid.Parse(userID)
+		h.rejectProfanity(w, r, uid, "username contains inappropriate content")
 		return
 	}
 
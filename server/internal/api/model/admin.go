@@ -0,0 +1,39 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+)
+
+type SetUserRoleReq struct {
+	Role string `json:"role"`
+}
+
+type GrantAchievementReq struct {
+	AchievementID uuid.UUID `json:"achievement_id"`
+}
+
+// AdminUsersResponse is a page of users matching an admin search
+type AdminUsersResponse struct {
+	Users []*userRepo.User `json:"users"`
+	// Total is the number of users matching the search, TotalUsers the number of users overall
+	Total      int `json:"total"`
+	TotalUsers int `json:"total_users"`
+	Limit      int `json:"limit"`
+	Offset     int `json:"offset"`
+}
+
+// AuditLogResponse is a page of the moderation audit log, newest first
+type AuditLogResponse struct {
+	Entries []*auditRepo.Entry `json:"entries"`
+	Total   int                `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+}
+
+// ExplainProfanityReq is a text to run through the profanity filter
+// without acting on it
+type ExplainProfanityReq struct {
+	Text string `json:"text"`
+}
@@ -1,10 +1,43 @@
 package model
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+// RoomCategories are the categories a room can be filed under
+var RoomCategories = []string{
+	"general",
+	"tech",
+	"gaming",
+	"music",
+	"sports",
+	"news",
+	"entertainment",
+	"education",
+	"random",
+}
+
+func IsRoomCategory(category string) bool {
+	return slices.Contains(RoomCategories, category)
+}
 
 type CreateRoomReq struct {
-	ID   string `json:"id,omitempty"`
-	Name string `json:"name"`
+	ID       string   `json:"id,omitempty"`
+	Name     string   `json:"name"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// UpdateRoomReq is a partial update of a room's settings. Omitted fields are left unchanged.
+type UpdateRoomReq struct {
+	Name             *string `json:"name,omitempty"`
+	Description      *string `json:"description,omitempty"`
+	Rules            *string `json:"rules,omitempty"`
+	TopicTitle       *string `json:"topic_title,omitempty"`
+	TopicDescription *string `json:"topic_description,omitempty"`
+	TopicURL         *string `json:"topic_url,omitempty"`
+	SlowModeSeconds  *int    `json:"slow_mode_seconds,omitempty"`
 }
 
 type ClientRes struct {
@@ -13,13 +46,38 @@ type ClientRes struct {
 }
 
 type RoomRes struct {
-	ID               string    `json:"id"`
-	Name             string    `json:"name"`
-	IsPinned         bool      `json:"is_pinned"`
-	CreatedAt        time.Time `json:"created_at"`
-	ExpiresAt        time.Time `json:"expires_at"`
-	TopicTitle       *string   `json:"topic_title,omitempty"`
-	TopicDescription *string   `json:"topic_description,omitempty"`
-	TopicURL         *string   `json:"topic_url,omitempty"`
-	TopicSource      *string   `json:"topic_source,omitempty"`
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	IsPinned         bool       `json:"is_pinned"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	TopicTitle       *string    `json:"topic_title,omitempty"`
+	TopicDescription *string    `json:"topic_description,omitempty"`
+	TopicURL         *string    `json:"topic_url,omitempty"`
+	TopicSource      *string    `json:"topic_source,omitempty"`
+	Category         *string    `json:"category,omitempty"`
+	Tags             []string   `json:"tags"`
+	Description      *string    `json:"description,omitempty"`
+	Rules            *string    `json:"rules,omitempty"`
+	SlowModeSeconds  int        `json:"slow_mode_seconds"`
+	ConnectedUsers   int        `json:"connected_users"`
+	MessagesLastHour int        `json:"messages_last_hour"`
+	LastActivityAt   *time.Time `json:"last_activity_at,omitempty"`
+}
+
+type MessageRes struct {
+	ID        string    `json:"id"`
+	RoomID    string    `json:"room_id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content"`
+	System    bool      `json:"system"`
+	Timestamp time.Time `json:"timestamp"`
+	Seq       int64     `json:"seq"`
+}
+
+type MessageHistoryRes struct {
+	Messages   []MessageRes `json:"messages"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	HasMore    bool         `json:"has_more"`
 }
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+)
+
+// CreateReportReq reports a message (room_id and message_seq), a user
+// (user_id) or a room (room_id)
+type CreateReportReq struct {
+	TargetType string     `json:"target_type"`
+	RoomID     *uuid.UUID `json:"room_id,omitempty"`
+	MessageSeq int64      `json:"message_seq,omitempty"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+}
+
+// ResolveReportReq closes a report. DurationMinutes sets how long a mute or
+// ban lasts; bans without one are permanent.
+type ResolveReportReq struct {
+	Action          string `json:"action"`
+	Note            string `json:"note,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+}
+
+// ReportsResponse is a page of the moderation queue
+type ReportsResponse struct {
+	Reports []*moderationRepo.Report `json:"reports"`
+	Total   int                      `json:"total"`
+	Limit   int                      `json:"limit"`
+	Offset  int                      `json:"offset"`
+}
+
+// CreateSanctionReq mutes, bans or shadow-bans a user directly.
+// DurationMinutes sets how long it lasts; mutes default to a day and bans
+// without one are permanent. Bans and shadow-bans need a reason.
+type CreateSanctionReq struct {
+	UserID          uuid.UUID `json:"user_id"`
+	Kind            string    `json:"kind"`
+	Reason          string    `json:"reason,omitempty"`
+	DurationMinutes int       `json:"duration_minutes,omitempty"`
+}
+
+// CreateIPBanReq bans an address or a CIDR network. Bans without
+// DurationMinutes are permanent.
+type CreateIPBanReq struct {
+	CIDR            string `json:"cidr"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+}
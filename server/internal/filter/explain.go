@@ -0,0 +1,131 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Match is a banned word found in a text. Start and End are byte offsets
+// into the normalized text.
+type Match struct {
+	Word     string `json:"word"`
+	Matched  string `json:"matched"`
+	Language string `json:"language,omitempty"`
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Blocked  bool   `json:"blocked"`
+	Reason   string `json:"reason"`
+}
+
+// AllowedPhrase is an allowed phrase found in a text
+type AllowedPhrase struct {
+	Phrase string `json:"phrase"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+}
+
+// Explanation shows which rules matched a text and why it would or would
+// not be blocked
+type Explanation struct {
+	Text    string          `json:"text"`
+	Blocked bool            `json:"blocked"`
+	Matches []Match         `json:"matches"`
+	Allowed []AllowedPhrase `json:"allowed"`
+}
+
+// Explain checks the text like ContainsProfanity and reports every match
+func (pf *ProfanityFilter) Explain(text string) *Explanation {
+	return pf.rules.Load().check(normalize(text), false)
+}
+
+// normalize prepares text for matching
+func normalize(text string) string {
+	return strings.ToLower(strings.TrimSpace(text))
+}
+
+// check matches the text against every rule. With firstBlock set it skips
+// low-severity rules and stops at the first blocking match, for callers
+// that only need the verdict.
+func (set *ruleSet) check(text string, firstBlock bool) *Explanation {
+	exp := &Explanation{Text: text, Matches: []Match{}, Allowed: []AllowedPhrase{}}
+
+	// Allowed phrases are only looked for once something matched
+	allowedFound := false
+	findAllowed := func() {
+		if allowedFound {
+			return
+		}
+		allowedFound = true
+		for _, a := range set.allow {
+			for _, loc := range a.pattern.FindAllStringIndex(text, -1) {
+				exp.Allowed = append(exp.Allowed, AllowedPhrase{Phrase: a.phrase, Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+
+	for _, r := range set.rules {
+		if firstBlock && r.severity == SeverityLow {
+			continue
+		}
+
+		for _, loc := range r.pattern.FindAllStringIndex(text, -1) {
+			findAllowed()
+
+			m := Match{
+				Word:     r.word,
+				Matched:  text[loc[0]:loc[1]],
+				Language: r.language,
+				Category: r.category,
+				Severity: r.severity,
+				Start:    loc[0],
+				End:      loc[1],
+			}
+
+			if phrase, ok := exp.allowedAround(loc[0], loc[1]); ok {
+				m.Reason = fmt.Sprintf("inside the allowed phrase %q", phrase)
+			} else if r.severity == SeverityLow {
+				m.Reason = fmt.Sprintf("%q is low severity, so it is only reported", r.word)
+			} else {
+				m.Blocked = true
+				m.Reason = r.describe()
+			}
+
+			exp.Matches = append(exp.Matches, m)
+			if m.Blocked {
+				exp.Blocked = true
+				if firstBlock {
+					return exp
+				}
+			}
+		}
+	}
+
+	return exp
+}
+
+// allowedAround returns the allowed phrase that covers the span, if any
+func (exp *Explanation) allowedAround(start, end int) (string, bool) {
+	for _, a := range exp.Allowed {
+		if a.Start <= start && end <= a.End {
+			return a.Phrase, true
+		}
+	}
+	return "", false
+}
+
+// describe explains why a match of the rule blocks
+func (r *rule) describe() string {
+	spelling := fmt.Sprintf("%q", r.word)
+	if r.variant != r.word {
+		spelling = fmt.Sprintf("%q, a substituted spelling of %q,", r.variant, r.word)
+	}
+
+	category := r.category
+	if r.language != "" {
+		category = r.language + " " + category
+	}
+
+	return fmt.Sprintf("contains the word %s which is %s severity (%s)", spelling, r.severity, category)
+}
@@ -1,123 +1,123 @@
 package filter
 
 import (
-	"regexp"
-	"strings"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// ProfanityFilter contains the filtering logic for inappropriate content
+const builtInSource = "built-in"
+
+// ProfanityFilter contains the filtering logic for inappropriate content.
+// Its word lists can be reloaded while it is in use.
 type ProfanityFilter struct {
-	bannedWords []string
-	patterns    []*regexp.Regexp
+	path  string
+	rules atomic.Pointer[ruleSet]
+
+	// reloadMu serialises reloads and guards the file's last seen state
+	reloadMu sync.Mutex
+	modTime  time.Time
+	size     int64
 }
 
-// NewProfanityFilter creates a new profanity filter with a comprehensive list of banned words
-func NewProfanityFilter() *ProfanityFilter {
-	filter := &ProfanityFilter{
-		bannedWords: getBannedWords(),
+// NewProfanityFilter creates a profanity filter with the word lists in the
+// JSON file at path, or the built-in lists if path is empty
+func NewProfanityFilter(path string) (*ProfanityFilter, error) {
+	pf := &ProfanityFilter{path: path}
+	if _, err := pf.Reload(); err != nil {
+		return nil, err
 	}
-
-	// Compile regex patterns for banned words (case-insensitive, with word boundaries)
-	for _, word := range filter.bannedWords {
-		// Create pattern that matches the word with optional special characters
-		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
-		filter.patterns = append(filter.patterns, pattern)
-
-		// Also check for l33t speak and common substitutions
-		substituted := applyCommonSubstitutions(word)
-		if substituted != word {
-			substPattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(substituted) + `\b`)
-			filter.patterns = append(filter.patterns, substPattern)
-		}
-	}
-
-	return filter
+	return pf, nil
 }
 
-// ContainsProfanity checks if the given text contains profanity
+// ContainsProfanity checks if the given text contains a banned word of
+// medium or high severity that isn't part of an allowed phrase
 func (pf *ProfanityFilter) ContainsProfanity(text string) bool {
-	// Normarize the text - remove extra spaces, convert to lowercase for checking
-	normalized := strings.ToLower(strings.TrimSpace(text))
-
-	// Check against all patterns
-	for _, pattern := range pf.patterns {
-		if pattern.MatchString(normalized) {
-			return true
-		}
-	}
+	return pf.rules.Load().check(normalize(text), true).Blocked
+}
 
-	return false
+// Stats describes the word lists in use
+func (pf *ProfanityFilter) Stats() WordListStats {
+	return pf.rules.Load().stats
 }
 
-// applyCommonSubstitutions applies common character substitutions used to bypass filters
-func applyCommonSubstitutions(word string) string {
-	substitutions := map[string]string{
-		"a": "@",
-		"e": "3",
-		"i": "1",
-		"o": "0",
-		"s": "$",
-		"t": "7",
+// Reload reads the word list file again. If it can't be loaded the current
+// lists stay in use.
+func (pf *ProfanityFilter) Reload() (WordListStats, error) {
+	pf.reloadMu.Lock()
+	defer pf.reloadMu.Unlock()
+
+	if pf.path == "" {
+		if pf.rules.Load() == nil {
+			cfg, err := parseWordLists(defaultWordLists)
+			if err != nil {
+				return WordListStats{}, err
+			}
+			pf.rules.Store(compile(cfg, builtInSource))
+		}
+		return pf.Stats(), nil
 	}
 
-	result := word
-	for original, substitute := range substitutions {
-		result = strings.ReplaceAll(result, original, substitute)
+	info, err := os.Stat(pf.path)
+	if err != nil {
+		return WordListStats{}, fmt.Errorf("read word lists: %w", err)
 	}
+	data, err := os.ReadFile(pf.path)
+	if err != nil {
+		return WordListStats{}, fmt.Errorf("read word lists: %w", err)
+	}
+	// A broken file is only reported once, not on every check until it's fixed
+	pf.modTime, pf.size = info.ModTime(), info.Size()
 
-	return result
-}
-
-// getBannedWords returns a comprehensive list of inappropriate words
-// This includes profanity, slurs, and racist terms
-func getBannedWords() []string {
-	return []string{
-		// Common profanity
-		"fuck", "shit", "damn", "bitch", "asshole", "basterd", "crap",
-		"piss", "dickhead", "jackass", "dumbass", "bullshit",
-
-		// Stronger profanity
-		"motherfucker", "cocksucker", "son of a bitch", "piece of shit",
-
-		// Sexual content
-		"porn", "sex", "naked", "nude", "xxx", "adult", "escort",
-
-		// Racist slurs and terms (censored for safety)
-		"nigger", "nigga", "negro", "spic", "wetback", "chink", "gook",
-		"kike", "hymie", "raghead", "towelhead", "sand nigger",
-		"cracker", "honky", "whity", "gringo", "beaner", "border hopper",
-
-		// Homophobic slurs
-		"faggot", "fag", "dyke", "homo", "queer", "tranny",
-
-		// Religious slurs
-		"kike", "hymie", "christ killer", "jihad", "terrorist",
-
-		// Disability slurs
-		"retard", "retarded", "spastic", "cripple", "invalid",
+	cfg, err := parseWordLists(data)
+	if err != nil {
+		return WordListStats{}, fmt.Errorf("%s: %w", pf.path, err)
+	}
 
-		// Common offensive terms
-		"nazi", "hitler", "genocide", "kill yourself", "kys",
-		"suicide", "die", "death", "murder", "rape",
+	set := compile(cfg, pf.path)
+	pf.rules.Store(set)
 
-		// Hate speech indicators
-		"hate", "supremacy", "master race", "inferior race",
-		"pure blood", "ethnic cleansing",
+	log.Printf("Loaded %d profanity patterns from %s", set.stats.Patterns, pf.path)
+	return set.stats, nil
+}
 
-		// Drug references
-		"cocaine", "heroin", "meth", "crack", "weed", "marijuana",
-		"drugs", "dealer", "pusher",
+// changed reports whether the word list file was modified since it was loaded
+func (pf *ProfanityFilter) changed() bool {
+	info, err := os.Stat(pf.path)
+	if err != nil {
+		return false
+	}
 
-		// Violence
-		"violence", "beating", "assault", "abuse", "torture",
-		"bomb", "explosion", "terrorist", "attack",
+	pf.reloadMu.Lock()
+	defer pf.reloadMu.Unlock()
+	return !info.ModTime().Equal(pf.modTime) || info.Size() != pf.size
+}
 
-		// Spam/inappropriate
-		"admin", "moderator", "official", "staff", "bot",
-		"advertisement", "promotion", "scam", "phishing",
+// Watch reloads the word lists whenever their file changes, checking every
+// interval until ctx is done. It returns straight away for the built-in lists.
+func (pf *ProfanityFilter) Watch(ctx context.Context, interval time.Duration) {
+	if pf.path == "" {
+		return
+	}
 
-		// Common variations and misspellings
-		"fuk", "shyt", "btch", "azz", "phuck", "biatch",
-		"n1gger", "n1gga", "f4ggot", "f4g", "sh1t", "fck",
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !pf.changed() {
+				continue
+			}
+			if _, err := pf.Reload(); err != nil {
+				log.Printf("Keeping the current profanity word lists: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
 	}
 }
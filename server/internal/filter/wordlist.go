@@ -0,0 +1,260 @@
+package filter
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultWordLists are used when no word list file is configured. They are
+// also an example of the file format.
+//
+//go:embed wordlists/default.json
+var defaultWordLists []byte
+
+// Severities of banned words
+const (
+	// SeverityLow words never block, they only show up in explanations
+	SeverityLow = "low"
+	// SeverityMedium words block
+	SeverityMedium = "medium"
+	// SeverityHigh words block like medium ones and mark the worst offences
+	SeverityHigh = "high"
+)
+
+func isSeverity(s string) bool {
+	return s == SeverityLow || s == SeverityMedium || s == SeverityHigh
+}
+
+// Entry is a banned word. In a file it is either a plain string, which takes
+// the list's severity, or an object with its own.
+type Entry struct {
+	Word     string `json:"word"`
+	Severity string `json:"severity,omitempty"`
+}
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var word string
+	if err := json.Unmarshal(data, &word); err == nil {
+		*e = Entry{Word: word}
+		return nil
+	}
+
+	type plainEntry Entry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var entry plainEntry
+	if err := dec.Decode(&entry); err != nil {
+		return fmt.Errorf("a word must be a string or an object with word and severity: %w", err)
+	}
+	*e = Entry(entry)
+	return nil
+}
+
+// WordList is a category of banned words in one language. Lists without a
+// language apply to every language, and words without a severity take the
+// list's, medium by default.
+type WordList struct {
+	Language string  `json:"language,omitempty"`
+	Category string  `json:"category"`
+	Severity string  `json:"severity,omitempty"`
+	Words    []Entry `json:"words"`
+}
+
+// WordListConfig is the format of a word list file
+type WordListConfig struct {
+	// Languages limits the filter to the lists in these languages, all of
+	// them if empty
+	Languages []string `json:"languages,omitempty"`
+	// Substitutions maps letters to the characters used to dodge the filter,
+	// e.g. "a" to "@"
+	Substitutions map[string]string `json:"substitutions,omitempty"`
+	// Allow lists phrases that override the banned words inside them, e.g.
+	// "sex education"
+	Allow []string   `json:"allow,omitempty"`
+	Lists []WordList `json:"lists"`
+}
+
+// ListStats describes one loaded word list
+type ListStats struct {
+	Language string `json:"language,omitempty"`
+	Category string `json:"category"`
+	Words    int    `json:"words"`
+}
+
+// WordListStats describes the word lists the filter is using
+type WordListStats struct {
+	// Source is the word list file, or "built-in"
+	Source    string      `json:"source"`
+	LoadedAt  time.Time   `json:"loaded_at"`
+	Languages []string    `json:"languages,omitempty"`
+	Lists     []ListStats `json:"lists"`
+	Patterns  int         `json:"patterns"`
+	Allowed   int         `json:"allowed_phrases"`
+}
+
+// rule is a compiled banned word, or one of its substituted spellings
+type rule struct {
+	word     string
+	variant  string
+	language string
+	category string
+	severity string
+	pattern  *regexp.Regexp
+}
+
+// allowRule is a compiled allowed phrase
+type allowRule struct {
+	phrase  string
+	pattern *regexp.Regexp
+}
+
+// ruleSet is everything the filter needs from one load of the word lists
+type ruleSet struct {
+	rules []rule
+	allow []allowRule
+	stats WordListStats
+}
+
+// parseWordLists decodes and validates a word list file
+func parseWordLists(data []byte) (*WordListConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg WordListConfig
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid word list file: %w", err)
+	}
+
+	if len(cfg.Lists) == 0 {
+		return nil, fmt.Errorf("invalid word list file: no lists")
+	}
+	for i, list := range cfg.Lists {
+		if strings.TrimSpace(list.Category) == "" {
+			return nil, fmt.Errorf("invalid word list file: list %d has no category", i)
+		}
+		if list.Severity != "" && !isSeverity(list.Severity) {
+			return nil, fmt.Errorf("invalid word list file: %s list has unknown severity %q", list.Category, list.Severity)
+		}
+		for _, entry := range list.Words {
+			if strings.TrimSpace(entry.Word) == "" {
+				return nil, fmt.Errorf("invalid word list file: empty word in %s list", list.Category)
+			}
+			if entry.Severity != "" && !isSeverity(entry.Severity) {
+				return nil, fmt.Errorf("invalid word list file: %q has unknown severity %q", entry.Word, entry.Severity)
+			}
+		}
+	}
+	for _, phrase := range cfg.Allow {
+		if strings.TrimSpace(phrase) == "" {
+			return nil, fmt.Errorf("invalid word list file: empty allowed phrase")
+		}
+	}
+	for original := range cfg.Substitutions {
+		if original == "" {
+			return nil, fmt.Errorf("invalid word list file: empty substitution")
+		}
+	}
+
+	return &cfg, nil
+}
+
+// compile builds the patterns for every word in the enabled languages. A
+// word is only kept in the first list it appears in for its language.
+func compile(cfg *WordListConfig, source string) *ruleSet {
+	languages := make([]string, 0, len(cfg.Languages))
+	for _, language := range cfg.Languages {
+		languages = append(languages, strings.ToLower(strings.TrimSpace(language)))
+	}
+
+	set := &ruleSet{stats: WordListStats{
+		Source:    source,
+		LoadedAt:  time.Now(),
+		Languages: languages,
+		Lists:     []ListStats{},
+	}}
+
+	substitute := substituter(cfg.Substitutions)
+	seen := make(map[string]bool)
+
+	for _, list := range cfg.Lists {
+		language := strings.ToLower(strings.TrimSpace(list.Language))
+		if language != "" && len(languages) > 0 && !slices.Contains(languages, language) {
+			continue
+		}
+		category := strings.ToLower(strings.TrimSpace(list.Category))
+
+		words := 0
+		for _, entry := range list.Words {
+			word := strings.ToLower(strings.TrimSpace(entry.Word))
+			if seen[language+":"+word] {
+				continue
+			}
+			seen[language+":"+word] = true
+			words++
+
+			severity := cmp.Or(entry.Severity, list.Severity, SeverityMedium)
+
+			// Match the word, and its l33t speak spelling
+			variants := []string{word}
+			if substituted := substitute.Replace(word); substituted != word {
+				variants = append(variants, substituted)
+			}
+			for _, variant := range variants {
+				set.rules = append(set.rules, rule{
+					word:     word,
+					variant:  variant,
+					language: language,
+					category: category,
+					severity: severity,
+					pattern:  wordPattern(variant),
+				})
+			}
+		}
+
+		set.stats.Lists = append(set.stats.Lists, ListStats{Language: language, Category: category, Words: words})
+	}
+
+	for _, phrase := range cfg.Allow {
+		phrase = strings.ToLower(strings.TrimSpace(phrase))
+		set.allow = append(set.allow, allowRule{phrase: phrase, pattern: wordPattern(phrase)})
+	}
+
+	set.stats.Patterns = len(set.rules)
+	set.stats.Allowed = len(set.allow)
+	return set
+}
+
+// wordPattern matches s case-insensitively as a whole word, so words are
+// never found inside longer ones
+func wordPattern(s string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(s) + `\b`)
+}
+
+// substituter replaces letters with the characters in the substitution map.
+// Longer keys are replaced first so the result doesn't depend on map order.
+func substituter(substitutions map[string]string) *strings.Replacer {
+	keys := make([]string, 0, len(substitutions))
+	for original := range substitutions {
+		keys = append(keys, original)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	pairs := make([]string, 0, 2*len(keys))
+	for _, original := range keys {
+		pairs = append(pairs, original, substitutions[original])
+	}
+	return strings.NewReplacer(pairs...)
+}
@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What was acted on
+const (
+	TargetUser     = "user"
+	TargetRoom     = "room"
+	TargetMessage  = "message"
+	TargetReport   = "report"
+	TargetIPBan    = "ip_ban"
+	TargetWordList = "wordlist"
+)
+
+// Audited actions
+const (
+	ActionUserDelete        = "user.delete"
+	ActionUserRoleChange    = "user.role_change"
+	ActionUserMessagesPurge = "user.messages_purge"
+	ActionAchievementGrant  = "achievement.grant"
+	ActionAchievementRevoke = "achievement.revoke"
+	ActionRoomDelete        = "room.delete"
+	ActionRoomExpire        = "room.expire"
+	ActionRoomMessagesPurge = "room.messages_purge"
+	ActionMessageDelete     = "message.delete"
+	ActionReportResolve     = "report.resolve"
+	ActionSanctionCreate    = "sanction.create"
+	ActionSanctionRevoke    = "sanction.revoke"
+	ActionIPBanCreate       = "ip_ban.create"
+	ActionIPBanRevoke       = "ip_ban.revoke"
+	ActionWordListReload    = "wordlist.reload"
+)
+
+// Entry is one moderation action. Before and After are JSON snapshots of the
+// target around the action, either may be empty.
+type Entry struct {
+	ID uuid.UUID `json:"id"`
+	// ActorID is nil for automatic actions and maintenance commands
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Reason     *string         `json:"reason,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Filter selects audit entries. Zero fields match everything; Since is
+// inclusive and Until exclusive.
+type Filter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// entryColumns is the column list scanned by scanEntry
+const entryColumns = `id, actor_id, action, target_type, target_id, reason, before, after, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row rowScanner) (*Entry, error) {
+	var e Entry
+	var before, after []byte
+	err := row.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID, &e.Reason, &before, &after, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(before) > 0 {
+		e.Before = json.RawMessage(before)
+	}
+	if len(after) > 0 {
+		e.After = json.RawMessage(after)
+	}
+	return &e, nil
+}
+
+// jsonArg passes a snapshot as text, or NULL if it is empty
+func jsonArg(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func (r *AuditRepository) Append(ctx context.Context, e *Entry) (*Entry, error) {
+	query := `
+		INSERT INTO moderation_audit_log (actor_id, action, target_type, target_id, reason, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7::jsonb)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, e.ActorID, e.Action, e.TargetType, e.TargetID, e.Reason, jsonArg(e.Before), jsonArg(e.After)).
+		Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert audit entry: %w", err)
+	}
+
+	return e, nil
+}
+
+// filterWhere builds the WHERE clause shared by the list, its count and the
+// export
+func filterWhere(f Filter) (string, []any) {
+	var args []any
+	conds := []string{"TRUE"}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.ActorID != nil {
+		add("actor_id = $%d", *f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id = $%d", f.TargetID)
+	}
+	if f.Since != nil {
+		add("created_at >= $%d", *f.Since)
+	}
+	if f.Until != nil {
+		add("created_at < $%d", *f.Until)
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func (r *AuditRepository) List(ctx context.Context, f Filter) ([]*Entry, int, error) {
+	where, args := filterWhere(f)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM moderation_audit_log WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count audit entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM moderation_audit_log
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, entryColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+func (r *AuditRepository) Export(ctx context.Context, f Filter, fn func(*Entry) error) error {
+	where, args := filterWhere(f)
+
+	query := `
+		SELECT ` + entryColumns + `
+		FROM moderation_audit_log
+		WHERE ` + where + `
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return fmt.Errorf("scan audit entry: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate audit entries: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package audit
+
+import "context"
+
+// AuditStore persists the moderation audit log. Entries can only be
+// appended. AuditRepository implements it on Postgres.
+type AuditStore interface {
+	Append(ctx context.Context, entry *Entry) (*Entry, error)
+	// List returns a page of entries matching the filter, newest first, and
+	// the number of entries matching it
+	List(ctx context.Context, filter Filter) ([]*Entry, int, error)
+	// Export calls fn for every entry matching the filter, oldest first,
+	// ignoring Limit and Offset. fn must not use the store.
+	Export(ctx context.Context, filter Filter, fn func(*Entry) error) error
+}
+
+var _ AuditStore = (*AuditRepository)(nil)
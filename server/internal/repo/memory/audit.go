@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+)
+
+// AuditRepository keeps the moderation audit log in memory, in the order
+// entries were appended
+type AuditRepository struct {
+	mu      sync.RWMutex
+	entries []*auditRepo.Entry
+}
+
+var _ auditRepo.AuditStore = (*AuditRepository)(nil)
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, e *auditRepo.Entry) (*auditRepo.Entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.ID = uuid.New()
+	e.CreatedAt = time.Now()
+
+	stored := *e
+	r.entries = append(r.entries, &stored)
+
+	return e, nil
+}
+
+func auditMatches(e *auditRepo.Entry, f auditRepo.Filter) bool {
+	return (f.ActorID == nil || (e.ActorID != nil && *e.ActorID == *f.ActorID)) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.TargetType == "" || e.TargetType == f.TargetType) &&
+		(f.TargetID == "" || e.TargetID == f.TargetID) &&
+		(f.Since == nil || !e.CreatedAt.Before(*f.Since)) &&
+		(f.Until == nil || e.CreatedAt.Before(*f.Until))
+}
+
+func (r *AuditRepository) List(ctx context.Context, f auditRepo.Filter) ([]*auditRepo.Entry, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*auditRepo.Entry
+	for _, e := range slices.Backward(r.entries) {
+		if auditMatches(e, f) {
+			matched = append(matched, e)
+		}
+	}
+
+	total := len(matched)
+	start := min(f.Offset, total)
+	end := min(start+f.Limit, total)
+
+	entries := make([]*auditRepo.Entry, 0, end-start)
+	for _, e := range matched[start:end] {
+		copied := *e
+		entries = append(entries, &copied)
+	}
+
+	return entries, total, nil
+}
+
+func (r *AuditRepository) Export(ctx context.Context, f auditRepo.Filter, fn func(*auditRepo.Entry) error) error {
+	r.mu.RLock()
+	var matched []*auditRepo.Entry
+	for _, e := range r.entries {
+		if auditMatches(e, f) {
+			copied := *e
+			matched = append(matched, &copied)
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, e := range matched {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+)
+
+// ModerationRepository keeps reports, sanctions, IP bans and strike
+// counters in memory. Reports are kept in the order they were filed.
+type ModerationRepository struct {
+	mu        sync.RWMutex
+	reports   []*moderationRepo.Report
+	sanctions []*moderationRepo.Sanction
+	ipBans    []*moderationRepo.IPBan
+	strikes   map[string]moderationRepo.StrikeCounter
+}
+
+var _ moderationRepo.ModerationStore = (*ModerationRepository)(nil)
+
+func NewModerationRepository() *ModerationRepository {
+	return &ModerationRepository{strikes: make(map[string]moderationRepo.StrikeCounter)}
+}
+
+func (r *ModerationRepository) CreateReport(ctx context.Context, report *moderationRepo.Report) (*moderationRepo.Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report.ID = uuid.New()
+	report.Status = moderationRepo.StatusOpen
+	report.CreatedAt = time.Now()
+
+	stored := *report
+	r.reports = append(r.reports, &stored)
+
+	return report, nil
+}
+
+func (r *ModerationRepository) GetReport(ctx context.Context, id uuid.UUID) (*moderationRepo.Report, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, report := range r.reports {
+		if report.ID == id {
+			copied := *report
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *ModerationRepository) ListReports(ctx context.Context, f moderationRepo.ReportFilter) ([]*moderationRepo.Report, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*moderationRepo.Report
+	for _, report := range r.reports {
+		if f.Status != "" && report.Status != f.Status {
+			continue
+		}
+		if f.TargetType != "" && report.TargetType != f.TargetType {
+			continue
+		}
+		copied := *report
+		matched = append(matched, &copied)
+	}
+
+	total := len(matched)
+	start := min(f.Offset, total)
+	end := min(start+f.Limit, total)
+
+	return matched[start:end], total, nil
+}
+
+func (r *ModerationRepository) ListReportsByReporter(ctx context.Context, reporterID uuid.UUID, limit int) ([]*moderationRepo.Report, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var reports []*moderationRepo.Report
+	for _, report := range slices.Backward(r.reports) {
+		if len(reports) == limit {
+			break
+		}
+		if report.ReporterID != nil && *report.ReporterID == reporterID {
+			copied := *report
+			reports = append(reports, &copied)
+		}
+	}
+
+	return reports, nil
+}
+
+func (r *ModerationRepository) ResolveReport(ctx context.Context, id uuid.UUID, res moderationRepo.Resolution) (*moderationRepo.Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, report := range r.reports {
+		if report.ID != id {
+			continue
+		}
+		if report.Status != moderationRepo.StatusOpen {
+			return nil, nil
+		}
+
+		now := time.Now()
+		action, resolvedBy := res.Action, res.ResolvedBy
+		report.Status = res.Status
+		report.Action = &action
+		report.ResolvedBy = &resolvedBy
+		report.ResolutionNote = res.Note
+		report.ResolvedAt = &now
+
+		copied := *report
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *ModerationRepository) ReopenReport(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, report := range r.reports {
+		if report.ID == id {
+			report.Status = moderationRepo.StatusOpen
+			report.Action = nil
+			report.ResolvedBy = nil
+			report.ResolutionNote = nil
+			report.ResolvedAt = nil
+		}
+	}
+	return nil
+}
+
+func (r *ModerationRepository) CreateSanction(ctx context.Context, s *moderationRepo.Sanction) (*moderationRepo.Sanction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now()
+
+	stored := *s
+	r.sanctions = append(r.sanctions, &stored)
+
+	return s, nil
+}
+
+func (r *ModerationRepository) GetActiveSanction(ctx context.Context, userID uuid.UUID, kind string) (*moderationRepo.Sanction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var longest *moderationRepo.Sanction
+	for _, s := range r.sanctions {
+		if s.UserID != userID || s.Kind != kind || !s.IsActive(now) {
+			continue
+		}
+		if longest == nil || outlasts(s, longest) {
+			longest = s
+		}
+	}
+
+	if longest == nil {
+		return nil, nil
+	}
+	copied := *longest
+	return &copied, nil
+}
+
+func (r *ModerationRepository) ListActiveSanctions(ctx context.Context, kind string) ([]*moderationRepo.Sanction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var sanctions []*moderationRepo.Sanction
+	for _, s := range r.sanctions {
+		if (kind == "" || s.Kind == kind) && s.IsActive(now) {
+			copied := *s
+			sanctions = append(sanctions, &copied)
+		}
+	}
+
+	return sanctions, nil
+}
+
+func (r *ModerationRepository) RevokeSanction(ctx context.Context, id, revokedBy uuid.UUID) (*moderationRepo.Sanction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, s := range r.sanctions {
+		if s.ID != id {
+			continue
+		}
+		if !s.IsActive(now) {
+			return nil, nil
+		}
+		s.RevokedAt = &now
+		s.RevokedBy = &revokedBy
+		copied := *s
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (r *ModerationRepository) CreateIPBan(ctx context.Context, b *moderationRepo.IPBan) (*moderationRepo.IPBan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b.ID = uuid.New()
+	b.CreatedAt = time.Now()
+
+	stored := *b
+	r.ipBans = append(r.ipBans, &stored)
+
+	return b, nil
+}
+
+func (r *ModerationRepository) ListActiveIPBans(ctx context.Context) ([]*moderationRepo.IPBan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var bans []*moderationRepo.IPBan
+	for _, b := range r.ipBans {
+		if b.IsActive(now) {
+			copied := *b
+			bans = append(bans, &copied)
+		}
+	}
+
+	return bans, nil
+}
+
+func (r *ModerationRepository) RevokeIPBan(ctx context.Context, id, revokedBy uuid.UUID) (*moderationRepo.IPBan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, b := range r.ipBans {
+		if b.ID != id {
+			continue
+		}
+		if !b.IsActive(now) {
+			return nil, nil
+		}
+		b.RevokedAt = &now
+		b.RevokedBy = &revokedBy
+		copied := *b
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (r *ModerationRepository) GetStrikeCounter(ctx context.Context, key string) (*moderationRepo.StrikeCounter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.strikes[key]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (r *ModerationRepository) SaveStrikeCounter(ctx context.Context, c *moderationRepo.StrikeCounter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.strikes[c.Key] = *c
+	return nil
+}
+
+func (r *ModerationRepository) DeleteExpiredStrikeCounters(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, c := range r.strikes {
+		if !c.ExpiresAt.After(now) {
+			delete(r.strikes, key)
+		}
+	}
+	return nil
+}
+
+// outlasts reports whether sanction a ends after b. Sanctions without an
+// expiry outlast everything.
+func outlasts(a, b *moderationRepo.Sanction) bool {
+	if a.ExpiresAt == nil {
+		return b.ExpiresAt != nil
+	}
+	return b.ExpiresAt != nil && a.ExpiresAt.After(*b.ExpiresAt)
+}
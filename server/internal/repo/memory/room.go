@@ -0,0 +1,615 @@
+// Package memory implements the repository stores in process memory. Data
+// lives only as long as the process, which makes the stores suited to tests
+// and local development.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+)
+
+// defaultRoomLifetime matches the expires_at column default
+const defaultRoomLifetime = 24 * time.Hour
+
+// RoomRepository keeps rooms and their messages in memory. It implements
+// both roomRepo.RoomStore and roomRepo.MessageStore.
+type RoomRepository struct {
+	mu       sync.RWMutex
+	rooms    map[uuid.UUID]*roomRepo.Room
+	messages map[uuid.UUID][]*roomRepo.Message // per room, ordered by seq
+}
+
+var (
+	_ roomRepo.RoomStore    = (*RoomRepository)(nil)
+	_ roomRepo.MessageStore = (*RoomRepository)(nil)
+)
+
+func NewRoomRepository() *RoomRepository {
+	return &RoomRepository{
+		rooms:    make(map[uuid.UUID]*roomRepo.Room),
+		messages: make(map[uuid.UUID][]*roomRepo.Message),
+	}
+}
+
+func (r *RoomRepository) CreateRoom(ctx context.Context, room *roomRepo.Room) (*roomRepo.Room, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if room.Tags == nil {
+		room.Tags = []string{}
+	}
+
+	room.ID = uuid.New()
+	room.CreatedAt = now
+	room.UpdatedAt = now
+	// Only pinned rooms choose their own expiry, like the Postgres repository
+	if !room.IsPinned || room.ExpiresAt.IsZero() {
+		room.ExpiresAt = now.Add(defaultRoomLifetime)
+	}
+
+	r.rooms[room.ID] = copyRoom(room)
+	return room, nil
+}
+
+func (r *RoomRepository) GetRoomByID(ctx context.Context, id uuid.UUID) (*roomRepo.Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	room, ok := r.rooms[id]
+	if !ok || !isActive(room, time.Now()) {
+		return nil, nil
+	}
+	return r.snapshot(room), nil
+}
+
+func (r *RoomRepository) GetRoomByIDIncludingArchived(ctx context.Context, id uuid.UUID) (*roomRepo.Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	room, ok := r.rooms[id]
+	if !ok || !isVisible(room, time.Now()) {
+		return nil, nil
+	}
+	return r.snapshot(room), nil
+}
+
+func (r *RoomRepository) GetAllActiveRooms(ctx context.Context) ([]*roomRepo.Room, error) {
+	return r.FilterActiveRooms(ctx, roomRepo.RoomFilter{})
+}
+
+func (r *RoomRepository) UpdateRoomSettings(ctx context.Context, id uuid.UUID, settings roomRepo.RoomSettings) (*roomRepo.Room, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	room, ok := r.rooms[id]
+	if !ok || !isActive(room, now) || room.IsArchived() {
+		return nil, nil
+	}
+
+	if settings.Name != nil {
+		room.Name = *settings.Name
+	}
+	if settings.Description != nil {
+		room.Description = copyString(settings.Description)
+	}
+	if settings.Rules != nil {
+		room.Rules = copyString(settings.Rules)
+	}
+	if settings.TopicTitle != nil || settings.TopicDescription != nil || settings.TopicURL != nil {
+		if settings.TopicTitle != nil {
+			room.TopicTitle = copyString(settings.TopicTitle)
+		}
+		if settings.TopicDescription != nil {
+			room.TopicDescription = copyString(settings.TopicDescription)
+		}
+		if settings.TopicURL != nil {
+			room.TopicURL = copyString(settings.TopicURL)
+		}
+		room.TopicUpdatedAt = &now
+	}
+	if settings.SlowModeSeconds != nil {
+		room.SlowModeSeconds = *settings.SlowModeSeconds
+	}
+	room.UpdatedAt = now
+
+	return r.snapshot(room), nil
+}
+
+func (r *RoomRepository) ListActiveRooms(ctx context.Context, filter roomRepo.RoomFilter, sort roomRepo.RoomSort, after *roomRepo.RoomCursor, limit int) ([]*roomRepo.Room, *roomRepo.RoomCursor, error) {
+	rooms, err := r.FilterActiveRooms(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmp := comparePinnedFirst
+	if sort == roomRepo.RoomSortNewest {
+		cmp = compareNewest
+	}
+	slices.SortFunc(rooms, cmp)
+
+	if after != nil {
+		cursor := &roomRepo.Room{IsPinned: after.IsPinned, CreatedAt: after.CreatedAt, ID: after.ID}
+		start := len(rooms)
+		for i, room := range rooms {
+			if cmp(room, cursor) > 0 {
+				start = i
+				break
+			}
+		}
+		rooms = rooms[start:]
+	}
+
+	var next *roomRepo.RoomCursor
+	if len(rooms) > limit {
+		rooms = rooms[:limit]
+		last := rooms[len(rooms)-1]
+		next = &roomRepo.RoomCursor{IsPinned: last.IsPinned, CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return rooms, next, nil
+}
+
+func (r *RoomRepository) FilterActiveRooms(ctx context.Context, filter roomRepo.RoomFilter) ([]*roomRepo.Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var rooms []*roomRepo.Room
+	for _, room := range r.rooms {
+		if isActive(room, now) && matches(room, filter) {
+			rooms = append(rooms, r.snapshot(room))
+		}
+	}
+	slices.SortFunc(rooms, comparePinnedFirst)
+
+	return rooms, nil
+}
+
+func (r *RoomRepository) GetRoomActivity(ctx context.Context, roomIDs []uuid.UUID) (map[uuid.UUID]roomRepo.RoomActivity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	activity := make(map[uuid.UUID]roomRepo.RoomActivity, len(roomIDs))
+	for _, id := range roomIDs {
+		msgs := r.messages[id]
+		if len(msgs) == 0 {
+			continue
+		}
+
+		var a roomRepo.RoomActivity
+		for _, msg := range msgs {
+			if msg.CreatedAt.After(now.Add(-time.Hour)) {
+				a.MessagesLastHour++
+			}
+			if msg.CreatedAt.After(now.Add(-15 * time.Minute)) {
+				a.MessagesLast15Min++
+			}
+			if a.LastActivityAt == nil || msg.CreatedAt.After(*a.LastActivityAt) {
+				createdAt := msg.CreatedAt
+				a.LastActivityAt = &createdAt
+			}
+		}
+		activity[id] = a
+	}
+
+	return activity, nil
+}
+
+func (r *RoomRepository) CountFilteredRooms(ctx context.Context, filter roomRepo.RoomFilter) (int, error) {
+	rooms, err := r.FilterActiveRooms(ctx, filter)
+	return len(rooms), err
+}
+
+func (r *RoomRepository) CountActiveRooms(ctx context.Context) (int, error) {
+	return r.CountFilteredRooms(ctx, roomRepo.RoomFilter{})
+}
+
+func (r *RoomRepository) DeleteExpiredRooms(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for id, room := range r.rooms {
+		if !isActive(room, now) {
+			r.deleteRoom(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *RoomRepository) ArchiveExpiredRooms(ctx context.Context) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var ids []uuid.UUID
+	for id, room := range r.rooms {
+		if !isActive(room, now) && !room.IsArchived() {
+			archivedAt := now
+			room.ArchivedAt = &archivedAt
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (r *RoomRepository) PurgeArchivedRooms(ctx context.Context, retention time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().Add(-retention)
+	count := 0
+	for id, room := range r.rooms {
+		if room.ArchivedAt != nil && !room.ArchivedAt.After(cutoff) {
+			r.deleteRoom(id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *RoomRepository) ExtendRoom(ctx context.Context, id uuid.UUID, by, maxLifetime time.Duration) (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	room, ok := r.rooms[id]
+	if !ok || !isActive(room, time.Now()) || room.IsArchived() {
+		return nil, nil
+	}
+
+	extended := room.ExpiresAt.Add(by)
+	if limit := room.CreatedAt.Add(maxLifetime); extended.After(limit) {
+		extended = limit
+	}
+	if extended.After(room.ExpiresAt) {
+		room.ExpiresAt = extended
+	}
+
+	expiresAt := room.ExpiresAt
+	return &expiresAt, nil
+}
+
+func (r *RoomRepository) HasActiveRoom(ctx context.Context, userID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for _, room := range r.rooms {
+		if room.CreatorID != nil && *room.CreatorID == userID && isActive(room, now) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *RoomRepository) CountPinnedRooms(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	count := 0
+	for _, room := range r.rooms {
+		if room.IsPinned && isActive(room, now) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *RoomRepository) CreateMessage(ctx context.Context, msg *roomRepo.Message) (*roomRepo.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg.ID = uuid.New()
+	msg.CreatedAt = time.Now()
+	r.insertMessage(msg)
+
+	return msg, nil
+}
+
+func (r *RoomRepository) CreateMessages(ctx context.Context, msgs []*roomRepo.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, msg := range msgs {
+		msg.ID = uuid.New()
+		if msg.CreatedAt.IsZero() {
+			msg.CreatedAt = time.Now()
+		}
+		r.insertMessage(msg)
+	}
+
+	return nil
+}
+
+func (r *RoomRepository) GetRoomMessages(ctx context.Context, roomID uuid.UUID, limit int) ([]*roomRepo.Message, error) {
+	messages, _, err := r.GetRoomMessagesBefore(ctx, roomID, nil, limit)
+	return messages, err
+}
+
+func (r *RoomRepository) GetRoomMessagesBefore(ctx context.Context, roomID uuid.UUID, before *roomRepo.MessageCursor, limit int) ([]*roomRepo.Message, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.roomVisible(roomID) {
+		return nil, false, nil
+	}
+
+	msgs := r.messages[roomID]
+	if before != nil {
+		end, _ := slices.BinarySearchFunc(msgs, before.Seq, func(m *roomRepo.Message, seq int64) int {
+			return compareInt64(m.Seq, seq)
+		})
+		msgs = msgs[:end]
+	}
+
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[len(msgs)-limit:]
+	}
+
+	return copyMessages(msgs), hasMore, nil
+}
+
+func (r *RoomRepository) GetRoomMessagesAfter(ctx context.Context, roomID uuid.UUID, afterSeq int64, limit int) ([]*roomRepo.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.roomVisible(roomID) {
+		return nil, nil
+	}
+
+	msgs := r.messages[roomID]
+	start, _ := slices.BinarySearchFunc(msgs, afterSeq+1, func(m *roomRepo.Message, seq int64) int {
+		return compareInt64(m.Seq, seq)
+	})
+	msgs = msgs[start:]
+	if len(msgs) > limit {
+		msgs = msgs[:limit]
+	}
+
+	return copyMessages(msgs), nil
+}
+
+func (r *RoomRepository) StreamRoomMessages(ctx context.Context, roomID uuid.UUID, fn func(*roomRepo.Message) error) error {
+	r.mu.RLock()
+	msgs := copyMessages(r.messages[roomID])
+	r.mu.RUnlock()
+
+	for _, msg := range msgs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *RoomRepository) IsRoomParticipant(ctx context.Context, roomID, userID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, msg := range r.messages[roomID] {
+		if msg.Authenticated && msg.UserID != nil && *msg.UserID == userID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *RoomRepository) DeleteRoom(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rooms[id]; !ok {
+		return false, nil
+	}
+	r.deleteRoom(id)
+
+	return true, nil
+}
+
+func (r *RoomRepository) ExpireRoom(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	room, ok := r.rooms[id]
+	if !ok || !isActive(room, now) || room.IsArchived() {
+		return false, nil
+	}
+
+	room.ExpiresAt = now
+	room.UpdatedAt = now
+
+	return true, nil
+}
+
+func (r *RoomRepository) GetMessageBySeq(ctx context.Context, roomID uuid.UUID, seq int64) (*roomRepo.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	msgs := r.messages[roomID]
+	i, found := slices.BinarySearchFunc(msgs, seq, func(m *roomRepo.Message, seq int64) int {
+		return compareInt64(m.Seq, seq)
+	})
+	if !found {
+		return nil, nil
+	}
+
+	msg := *msgs[i]
+	return &msg, nil
+}
+
+func (r *RoomRepository) DeleteMessage(ctx context.Context, roomID uuid.UUID, seq int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msgs := r.messages[roomID]
+	i, found := slices.BinarySearchFunc(msgs, seq, func(m *roomRepo.Message, seq int64) int {
+		return compareInt64(m.Seq, seq)
+	})
+	if !found {
+		return false, nil
+	}
+	r.messages[roomID] = slices.Delete(msgs, i, i+1)
+
+	return true, nil
+}
+
+func (r *RoomRepository) DeleteRoomMessages(ctx context.Context, roomID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := len(r.messages[roomID])
+	delete(r.messages, roomID)
+
+	return count, nil
+}
+
+func (r *RoomRepository) DeleteUserMessages(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for roomID, msgs := range r.messages {
+		kept := slices.DeleteFunc(msgs, func(m *roomRepo.Message) bool {
+			return m.UserID != nil && *m.UserID == userID
+		})
+		count += len(msgs) - len(kept)
+		r.messages[roomID] = kept
+	}
+
+	return count, nil
+}
+
+// insertMessage stores a copy of msg, keeping the room's messages ordered by seq.
+// The caller must hold the write lock.
+func (r *RoomRepository) insertMessage(msg *roomRepo.Message) {
+	stored := *msg
+	if msg.UserID != nil {
+		userID := *msg.UserID
+		stored.UserID = &userID
+	}
+
+	msgs := r.messages[msg.RoomID]
+	i, _ := slices.BinarySearchFunc(msgs, msg.Seq+1, func(m *roomRepo.Message, seq int64) int {
+		return compareInt64(m.Seq, seq)
+	})
+	r.messages[msg.RoomID] = slices.Insert(msgs, i, &stored)
+}
+
+// deleteRoom removes a room and its messages. The caller must hold the write lock.
+func (r *RoomRepository) deleteRoom(id uuid.UUID) {
+	delete(r.rooms, id)
+	delete(r.messages, id)
+}
+
+// roomVisible mirrors the join on rooms in the Postgres message queries.
+// The caller must hold the lock.
+func (r *RoomRepository) roomVisible(id uuid.UUID) bool {
+	room, ok := r.rooms[id]
+	return ok && isVisible(room, time.Now())
+}
+
+// snapshot copies a room for the caller and fills in LastSeq.
+// The caller must hold the lock.
+func (r *RoomRepository) snapshot(room *roomRepo.Room) *roomRepo.Room {
+	cp := copyRoom(room)
+	if msgs := r.messages[room.ID]; len(msgs) > 0 {
+		cp.LastSeq = msgs[len(msgs)-1].Seq
+	}
+	return cp
+}
+
+func isActive(room *roomRepo.Room, now time.Time) bool {
+	return room.ExpiresAt.After(now)
+}
+
+func isVisible(room *roomRepo.Room, now time.Time) bool {
+	return isActive(room, now) || room.IsArchived()
+}
+
+func matches(room *roomRepo.Room, filter roomRepo.RoomFilter) bool {
+	if filter.Category != "" && (room.Category == nil || *room.Category != filter.Category) {
+		return false
+	}
+	if filter.Tag != "" && !slices.Contains(room.Tags, filter.Tag) {
+		return false
+	}
+	if filter.Search != "" && !strings.Contains(strings.ToLower(room.Name), strings.ToLower(filter.Search)) {
+		return false
+	}
+	return true
+}
+
+// comparePinnedFirst orders rooms by (is_pinned, created_at, id) descending
+func comparePinnedFirst(a, b *roomRepo.Room) int {
+	if a.IsPinned != b.IsPinned {
+		if a.IsPinned {
+			return -1
+		}
+		return 1
+	}
+	return compareNewest(a, b)
+}
+
+// compareNewest orders rooms by (created_at, id) descending
+func compareNewest(a, b *roomRepo.Room) int {
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(b.ID[:], a.ID[:])
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func copyRoom(room *roomRepo.Room) *roomRepo.Room {
+	cp := *room
+	cp.Tags = slices.Clone(room.Tags)
+	return &cp
+}
+
+func copyMessages(msgs []*roomRepo.Message) []*roomRepo.Message {
+	out := make([]*roomRepo.Message, len(msgs))
+	for i, msg := range msgs {
+		cp := *msg
+		out[i] = &cp
+	}
+	return out
+}
+
+func copyString(s *string) *string {
+	cp := *s
+	return &cp
+}
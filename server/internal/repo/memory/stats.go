@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	statsRepo "github.com/momomo0206/go-chat-app/internal/repo/stats"
+)
+
+// defaultAchievements mirrors the achievement types seeded by the migrations
+var defaultAchievements = []statsRepo.Achievement{
+	{Name: "First Steps", Description: "Complete your first daily check-in", Icon: "🌟", ThresholdType: "streak", ThresholdValue: 1},
+	{Name: "Weekly Warrior", Description: "Maintain a 7-day streak", Icon: "🔥", ThresholdType: "streak", ThresholdValue: 7},
+	{Name: "Monthly Master", Description: "Maintain a 30-day streak", Icon: "👑", ThresholdType: "streak", ThresholdValue: 30},
+	{Name: "Chatter", Description: "Send your first messages", Icon: "💬", ThresholdType: "messages", ThresholdValue: 10},
+	{Name: "Conversationalist", Description: "Send 100 messages", Icon: "🗣️", ThresholdType: "messages", ThresholdValue: 100},
+	{Name: "Popular", Description: "Receive your first 5 upvotes", Icon: "✨", ThresholdType: "upvotes", ThresholdValue: 5},
+	{Name: "Beloved", Description: "Receive 25 upvotes", Icon: "💖", ThresholdType: "upvotes", ThresholdValue: 25},
+}
+
+type upvoteKey struct {
+	from, to uuid.UUID
+}
+
+// StatsRepository keeps user stats, check-ins, upvotes and achievements in memory
+type StatsRepository struct {
+	mu           sync.Mutex
+	stats        map[uuid.UUID]*statsRepo.UserStats
+	checkins     []statsRepo.DailyCheckin
+	upvotes      map[upvoteKey]time.Time
+	achievements []statsRepo.Achievement
+	earned       map[uuid.UUID]map[uuid.UUID]time.Time // user -> achievement -> earned at
+}
+
+var _ statsRepo.StatsStore = (*StatsRepository)(nil)
+
+func NewStatsRepository() *StatsRepository {
+	achievements := make([]statsRepo.Achievement, len(defaultAchievements))
+	for i, ach := range defaultAchievements {
+		ach.ID = uuid.New()
+		achievements[i] = ach
+	}
+
+	return &StatsRepository{
+		stats:        make(map[uuid.UUID]*statsRepo.UserStats),
+		upvotes:      make(map[upvoteKey]time.Time),
+		achievements: achievements,
+		earned:       make(map[uuid.UUID]map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *StatsRepository) GetOrCreateUserStats(ctx context.Context, userID uuid.UUID) (*statsRepo.UserStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return copyStats(r.getOrCreate(userID)), nil
+}
+
+func (r *StatsRepository) ProcessDailyCheckin(ctx context.Context, userID uuid.UUID) (int, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	stats := r.getOrCreate(userID)
+
+	newStreak := 1
+	if stats.LastCheckinDate != nil {
+		lastCheckin := stats.LastCheckinDate.UTC().Truncate(24 * time.Hour)
+		if lastCheckin.Equal(today) {
+			return stats.DailyStreak, false, nil
+		}
+		if lastCheckin.Equal(today.Add(-24 * time.Hour)) {
+			newStreak = stats.DailyStreak + 1
+		}
+	}
+
+	stats.DailyStreak = newStreak
+	stats.TotalCheckins++
+	stats.LastCheckinDate = &today
+	stats.UpdatedAt = time.Now()
+
+	r.checkins = append(r.checkins, statsRepo.DailyCheckin{
+		ID:          uuid.New(),
+		UserID:      userID,
+		CheckinDate: today,
+		StreakCount: newStreak,
+		CreatedAt:   time.Now(),
+	})
+
+	return newStreak, true, nil
+}
+
+func (r *StatsRepository) CanUserUpvote(ctx context.Context, fromUserID, toUserID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.upvotes[upvoteKey{from: fromUserID, to: toUserID}]; ok {
+		return false, nil
+	}
+
+	if stats, ok := r.stats[fromUserID]; ok && stats.LastUpvoteGivenDate != nil {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		if stats.LastUpvoteGivenDate.UTC().Truncate(24 * time.Hour).Equal(today) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (r *StatsRepository) GiveUpvote(ctx context.Context, fromUserID, toUserID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fromUserID == toUserID {
+		return errors.New("users can't upvote themselves")
+	}
+	key := upvoteKey{from: fromUserID, to: toUserID}
+	if _, ok := r.upvotes[key]; ok {
+		return errors.New("upvote already exists")
+	}
+
+	now := time.Now()
+	today := now.UTC().Truncate(24 * time.Hour)
+	r.upvotes[key] = now
+
+	giver := r.getOrCreate(fromUserID)
+	giver.LastUpvoteGivenDate = &today
+	giver.UpdatedAt = now
+
+	receiver := r.getOrCreate(toUserID)
+	receiver.TotalUpvotesReceived++
+	receiver.UpdatedAt = now
+
+	return nil
+}
+
+func (r *StatsRepository) GetUserProfile(ctx context.Context, userID uuid.UUID) (*statsRepo.UserStats, error) {
+	return r.GetOrCreateUserStats(ctx, userID)
+}
+
+func (r *StatsRepository) IncrementMessageCount(ctx context.Context, userID uuid.UUID) error {
+	return r.IncrementMessageCounts(ctx, map[uuid.UUID]int{userID: 1})
+}
+
+func (r *StatsRepository) IncrementMessageCounts(ctx context.Context, counts map[uuid.UUID]int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for userID, count := range counts {
+		stats := r.getOrCreate(userID)
+		stats.TotalMessages += count
+		stats.UpdatedAt = now
+	}
+
+	return nil
+}
+
+func (r *StatsRepository) CheckAndAwardAchievements(ctx context.Context, userID uuid.UUID) ([]statsRepo.Achievement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.getOrCreate(userID)
+	earned := r.earned[userID]
+	if earned == nil {
+		earned = make(map[uuid.UUID]time.Time)
+		r.earned[userID] = earned
+	}
+
+	newAchievements := []statsRepo.Achievement{}
+	for _, achType := range r.achievements {
+		if _, ok := earned[achType.ID]; ok {
+			continue
+		}
+
+		var currentValue int
+		switch achType.ThresholdType {
+		case "streak":
+			currentValue = stats.DailyStreak
+		case "messages":
+			currentValue = stats.TotalMessages
+		case "upvotes":
+			currentValue = stats.TotalUpvotesReceived
+		default:
+			continue
+		}
+
+		if currentValue >= achType.ThresholdValue {
+			earned[achType.ID] = time.Now()
+			newAchievements = append(newAchievements, achType)
+		}
+	}
+
+	return newAchievements, nil
+}
+
+func (r *StatsRepository) GetUserAchivementsWithDetails(ctx context.Context, userID uuid.UUID) ([]statsRepo.Achievement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var achievements []statsRepo.Achievement
+	for _, achType := range r.achievements {
+		earnedAt, ok := r.earned[userID][achType.ID]
+		if !ok {
+			continue
+		}
+		ach := achType
+		ach.EarnedAt = &earnedAt
+		achievements = append(achievements, ach)
+	}
+
+	slices.SortFunc(achievements, func(a, b statsRepo.Achievement) int {
+		return b.EarnedAt.Compare(*a.EarnedAt)
+	})
+
+	return achievements, nil
+}
+
+func (r *StatsRepository) GetAchievementTypes(ctx context.Context) ([]statsRepo.Achievement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.achievements), nil
+}
+
+func (r *StatsRepository) GrantAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !slices.ContainsFunc(r.achievements, func(a statsRepo.Achievement) bool { return a.ID == achievementID }) {
+		return false, statsRepo.ErrAchievementNotFound
+	}
+
+	earned := r.earned[userID]
+	if earned == nil {
+		earned = make(map[uuid.UUID]time.Time)
+		r.earned[userID] = earned
+	}
+	if _, ok := earned[achievementID]; ok {
+		return false, nil
+	}
+	earned[achievementID] = time.Now()
+
+	return true, nil
+}
+
+func (r *StatsRepository) RevokeAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.earned[userID][achievementID]; !ok {
+		return false, nil
+	}
+	delete(r.earned[userID], achievementID)
+
+	return true, nil
+}
+
+// getOrCreate returns the stored stats for the user, creating them if needed.
+// The caller must hold the lock.
+func (r *StatsRepository) getOrCreate(userID uuid.UUID) *statsRepo.UserStats {
+	stats, ok := r.stats[userID]
+	if !ok {
+		now := time.Now()
+		stats = &statsRepo.UserStats{
+			UserID:    userID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		r.stats[userID] = stats
+	}
+	return stats
+}
+
+func copyStats(stats *statsRepo.UserStats) *statsRepo.UserStats {
+	cp := *stats
+	return &cp
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestProcessDailyCheckinStreaks(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.Add(-24 * time.Hour)
+	twoDaysAgo := today.Add(-48 * time.Hour)
+
+	tests := []struct {
+		name        string
+		lastCheckin *time.Time
+		streak      int
+		wantStreak  int
+		wantNew     bool
+	}{
+		{name: "first check-in", wantStreak: 1, wantNew: true},
+		{name: "already checked in today", lastCheckin: &today, streak: 4, wantStreak: 4, wantNew: false},
+		{name: "checked in yesterday", lastCheckin: &yesterday, streak: 4, wantStreak: 5, wantNew: true},
+		{name: "missed a day", lastCheckin: &twoDaysAgo, streak: 4, wantStreak: 1, wantNew: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewStatsRepository()
+			userID := uuid.New()
+
+			stats := r.getOrCreate(userID)
+			stats.LastCheckinDate = tt.lastCheckin
+			stats.DailyStreak = tt.streak
+
+			streak, isNew, err := r.ProcessDailyCheckin(context.Background(), userID)
+			if err != nil {
+				t.Fatalf("ProcessDailyCheckin: %v", err)
+			}
+			if streak != tt.wantStreak || isNew != tt.wantNew {
+				t.Errorf("got streak %d, new %v; want streak %d, new %v", streak, isNew, tt.wantStreak, tt.wantNew)
+			}
+
+			// Checking in again the same day changes nothing
+			again, isNew, err := r.ProcessDailyCheckin(context.Background(), userID)
+			if err != nil {
+				t.Fatalf("second ProcessDailyCheckin: %v", err)
+			}
+			if again != tt.wantStreak || isNew {
+				t.Errorf("second check-in got streak %d, new %v; want streak %d, new false", again, isNew, tt.wantStreak)
+			}
+		})
+	}
+}
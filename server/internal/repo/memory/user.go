@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+)
+
+// UserRepository keeps user accounts in memory
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]*userRepo.User
+}
+
+var _ userRepo.UserStore = (*UserRepository)(nil)
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users: make(map[uuid.UUID]*userRepo.User),
+	}
+}
+
+func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*userRepo.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return copyUser(user), nil
+}
+
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*userRepo.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return copyUser(user), nil
+		}
+	}
+	return nil, nil // User not found
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *userRepo.User) (*userRepo.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Both columns are unique and Postgres reports either as the same error
+	for _, existing := range r.users {
+		if existing.Email == user.Email || existing.Username == user.Username {
+			return nil, errors.New("email already exists")
+		}
+	}
+
+	now := time.Now()
+	user.ID = uuid.New()
+	user.Role = userRepo.RoleUser
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.users[user.ID] = copyUser(user)
+
+	return user, nil
+}
+
+func (r *UserRepository) CountUsers(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.users), nil
+}
+
+func (r *UserRepository) SearchUsers(ctx context.Context, search string, limit, offset int) ([]*userRepo.User, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	search = strings.ToLower(search)
+	var matches []*userRepo.User
+	for _, user := range r.users {
+		if strings.Contains(strings.ToLower(user.Username), search) || strings.Contains(strings.ToLower(user.Email), search) {
+			matches = append(matches, user)
+		}
+	}
+
+	// Newest first, like the SQL repositories
+	slices.SortFunc(matches, func(a, b *userRepo.User) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(b.ID[:], a.ID[:])
+	})
+
+	total := len(matches)
+	matches = matches[min(offset, total):min(offset+limit, total)]
+
+	users := make([]*userRepo.User, len(matches))
+	for i, user := range matches {
+		users[i] = copyUser(user)
+	}
+	return users, total, nil
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return errors.New("user not found")
+	}
+	delete(r.users, id)
+
+	return nil
+}
+
+func (r *UserRepository) UpdateUsername(ctx context.Context, id uuid.UUID, username string) (*userRepo.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	for otherID, other := range r.users {
+		if otherID != id && other.Username == username {
+			return nil, errors.New("username already exists")
+		}
+	}
+
+	user.Username = username
+	user.UpdatedAt = time.Now()
+
+	return copyUser(user), nil
+}
+
+func (r *UserRepository) SetUserRole(ctx context.Context, id uuid.UUID, role string) (*userRepo.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+
+	return copyUser(user), nil
+}
+
+func copyUser(user *userRepo.User) *userRepo.User {
+	cp := *user
+	if user.PasswordHash != nil {
+		cp.PasswordHash = copyString(user.PasswordHash)
+	}
+	return &cp
+}
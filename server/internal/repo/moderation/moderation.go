@@ -0,0 +1,546 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What a report is about
+const (
+	TargetMessage = "message"
+	TargetUser    = "user"
+	TargetRoom    = "room"
+)
+
+// Report statuses. Open reports wait in the moderation queue.
+const (
+	StatusOpen      = "open"
+	StatusDismissed = "dismissed"
+	StatusActioned  = "actioned"
+)
+
+// What a moderator did about a report
+const (
+	ActionDismiss       = "dismiss"
+	ActionDeleteMessage = "delete_message"
+	ActionMute          = "mute"
+	ActionBan           = "ban"
+	ActionShadowBan     = "shadow_ban"
+)
+
+// Sanction kinds. Muted users can read but not send messages, banned users
+// can't log in or use the API, and shadow-banned users' messages are only
+// shown to themselves.
+const (
+	SanctionMute      = "mute"
+	SanctionBan       = "ban"
+	SanctionShadowBan = "shadow_ban"
+)
+
+type Report struct {
+	ID           uuid.UUID  `json:"id"`
+	ReporterID   *uuid.UUID `json:"reporter_id,omitempty"`
+	TargetType   string     `json:"target_type"`
+	TargetUserID *uuid.UUID `json:"target_user_id,omitempty"`
+	RoomID       *uuid.UUID `json:"room_id,omitempty"`
+	RoomName     *string    `json:"room_name,omitempty"`
+	// The reported message as it was when the report was filed
+	MessageSeq       *int64     `json:"message_seq,omitempty"`
+	MessageUsername  *string    `json:"message_username,omitempty"`
+	MessageContent   *string    `json:"message_content,omitempty"`
+	MessageCreatedAt *time.Time `json:"message_created_at,omitempty"`
+	Reason           string     `json:"reason"`
+	Details          *string    `json:"details,omitempty"`
+	Status           string     `json:"status"`
+	Action           *string    `json:"action,omitempty"`
+	ResolvedBy       *uuid.UUID `json:"resolved_by,omitempty"`
+	ResolutionNote   *string    `json:"resolution_note,omitempty"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// ReportFilter narrows the moderation queue. Empty fields match everything.
+type ReportFilter struct {
+	Status     string
+	TargetType string
+	Limit      int
+	Offset     int
+}
+
+// Resolution records how a moderator closed a report
+type Resolution struct {
+	Status     string
+	Action     string
+	ResolvedBy uuid.UUID
+	Note       *string
+}
+
+type Sanction struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Kind      string     `json:"kind"`
+	Reason    *string    `json:"reason,omitempty"`
+	ReportID  *uuid.UUID `json:"report_id,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	// ExpiresAt is nil for sanctions that never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy *uuid.UUID `json:"revoked_by,omitempty"`
+}
+
+// IsActive reports whether the sanction is still in force at now
+func (s *Sanction) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || s.ExpiresAt.After(now))
+}
+
+// IPBan bans every address in a network. CIDR is in canonical prefix form.
+type IPBan struct {
+	ID        uuid.UUID  `json:"id"`
+	CIDR      string     `json:"cidr"`
+	Reason    string     `json:"reason"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	// ExpiresAt is nil for bans that never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy *uuid.UUID `json:"revoked_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the ban is still in force at now
+func (b *IPBan) IsActive(now time.Time) bool {
+	return b.RevokedAt == nil && (b.ExpiresAt == nil || b.ExpiresAt.After(now))
+}
+
+// StrikeCounter counts the profanity strikes against a user ("user:<id>")
+// or an address ("ip:<addr>")
+type StrikeCounter struct {
+	Key       string    `json:"key"`
+	Count     int       `json:"count"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// ExpiresAt is when every strike will have decayed
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+func NewModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// reportColumns is the column list scanned by scanReport
+const reportColumns = `id, reporter_id, target_type, target_user_id, room_id, room_name,
+	message_seq, message_username, message_content, message_created_at,
+	reason, details, status, action, resolved_by, resolution_note, resolved_at, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReport(row rowScanner) (*Report, error) {
+	var report Report
+	err := row.Scan(
+		&report.ID,
+		&report.ReporterID,
+		&report.TargetType,
+		&report.TargetUserID,
+		&report.RoomID,
+		&report.RoomName,
+		&report.MessageSeq,
+		&report.MessageUsername,
+		&report.MessageContent,
+		&report.MessageCreatedAt,
+		&report.Reason,
+		&report.Details,
+		&report.Status,
+		&report.Action,
+		&report.ResolvedBy,
+		&report.ResolutionNote,
+		&report.ResolvedAt,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func scanReports(rows *sql.Rows) ([]*Report, error) {
+	var reports []*Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+func (r *ModerationRepository) CreateReport(ctx context.Context, report *Report) (*Report, error) {
+	query := `
+		INSERT INTO reports (reporter_id, target_type, target_user_id, room_id, room_name,
+			message_seq, message_username, message_content, message_created_at, reason, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, status, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		report.ReporterID, report.TargetType, report.TargetUserID, report.RoomID, report.RoomName,
+		report.MessageSeq, report.MessageUsername, report.MessageContent, report.MessageCreatedAt,
+		report.Reason, report.Details,
+	).Scan(&report.ID, &report.Status, &report.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert report: %w", err)
+	}
+
+	return report, nil
+}
+
+func (r *ModerationRepository) GetReport(ctx context.Context, id uuid.UUID) (*Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE id = $1`
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query report: %w", err)
+	}
+
+	return report, nil
+}
+
+// reportFilterWhere builds the WHERE clause shared by the queue and its count
+func reportFilterWhere(f ReportFilter) (string, []any) {
+	var args []any
+	conds := []string{"TRUE"}
+
+	if f.Status != "" {
+		args = append(args, f.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if f.TargetType != "" {
+		args = append(args, f.TargetType)
+		conds = append(conds, fmt.Sprintf("target_type = $%d", len(args)))
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func (r *ModerationRepository) ListReports(ctx context.Context, f ReportFilter) ([]*Report, int, error) {
+	where, args := reportFilterWhere(f)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count reports: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM reports
+		WHERE %s
+		ORDER BY created_at ASC, id ASC
+		LIMIT $%d OFFSET $%d
+	`, reportColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports, err := scanReports(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+// ListReportsByReporter returns the user's latest reports, newest first
+func (r *ModerationRepository) ListReportsByReporter(ctx context.Context, reporterID uuid.UUID, limit int) ([]*Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE reporter_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, reporterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query reports by reporter: %w", err)
+	}
+	defer rows.Close()
+
+	return scanReports(rows)
+}
+
+func (r *ModerationRepository) ResolveReport(ctx context.Context, id uuid.UUID, res Resolution) (*Report, error) {
+	query := `
+		UPDATE reports
+		SET status = $2, action = $3, resolved_by = $4, resolution_note = $5, resolved_at = NOW()
+		WHERE id = $1 AND status = 'open'
+		RETURNING ` + reportColumns
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, id, res.Status, res.Action, res.ResolvedBy, res.Note))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Report not found or already resolved
+		}
+		return nil, fmt.Errorf("resolve report: %w", err)
+	}
+
+	return report, nil
+}
+
+func (r *ModerationRepository) ReopenReport(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE reports
+		SET status = 'open', action = NULL, resolved_by = NULL, resolution_note = NULL, resolved_at = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("reopen report: %w", err)
+	}
+
+	return nil
+}
+
+// sanctionColumns is the column list scanned by scanSanction
+const sanctionColumns = `id, user_id, kind, reason, report_id, created_by, expires_at, created_at, revoked_at, revoked_by`
+
+func scanSanction(row rowScanner) (*Sanction, error) {
+	var s Sanction
+	err := row.Scan(&s.ID, &s.UserID, &s.Kind, &s.Reason, &s.ReportID, &s.CreatedBy, &s.ExpiresAt, &s.CreatedAt, &s.RevokedAt, &s.RevokedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *ModerationRepository) CreateSanction(ctx context.Context, s *Sanction) (*Sanction, error) {
+	query := `
+		INSERT INTO sanctions (user_id, kind, reason, report_id, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, s.UserID, s.Kind, s.Reason, s.ReportID, s.CreatedBy, s.ExpiresAt).
+		Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert sanction: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *ModerationRepository) GetActiveSanction(ctx context.Context, userID uuid.UUID, kind string) (*Sanction, error) {
+	query := `
+		SELECT ` + sanctionColumns + `
+		FROM sanctions
+		WHERE user_id = $1 AND kind = $2 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at DESC NULLS FIRST
+		LIMIT 1
+	`
+
+	s, err := scanSanction(r.db.QueryRowContext(ctx, query, userID, kind))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query active sanction: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *ModerationRepository) ListActiveSanctions(ctx context.Context, kind string) ([]*Sanction, error) {
+	query := `
+		SELECT ` + sanctionColumns + `
+		FROM sanctions
+		WHERE ($1 = '' OR kind = $1) AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, kind)
+	if err != nil {
+		return nil, fmt.Errorf("query active sanctions: %w", err)
+	}
+	defer rows.Close()
+
+	var sanctions []*Sanction
+	for rows.Next() {
+		s, err := scanSanction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan sanction: %w", err)
+		}
+		sanctions = append(sanctions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sanctions: %w", err)
+	}
+
+	return sanctions, nil
+}
+
+// RevokeSanction lifts an active sanction and returns it, or nil if it is
+// missing, expired or already lifted
+func (r *ModerationRepository) RevokeSanction(ctx context.Context, id, revokedBy uuid.UUID) (*Sanction, error) {
+	query := `
+		UPDATE sanctions
+		SET revoked_at = NOW(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING ` + sanctionColumns
+
+	s, err := scanSanction(r.db.QueryRowContext(ctx, query, id, revokedBy))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("revoke sanction: %w", err)
+	}
+
+	return s, nil
+}
+
+// ipBanColumns is the column list scanned by scanIPBan
+const ipBanColumns = `id, cidr, reason, created_by, expires_at, revoked_at, revoked_by, created_at`
+
+func scanIPBan(row rowScanner) (*IPBan, error) {
+	var b IPBan
+	err := row.Scan(&b.ID, &b.CIDR, &b.Reason, &b.CreatedBy, &b.ExpiresAt, &b.RevokedAt, &b.RevokedBy, &b.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func (r *ModerationRepository) CreateIPBan(ctx context.Context, b *IPBan) (*IPBan, error) {
+	query := `
+		INSERT INTO ip_bans (cidr, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, b.CIDR, b.Reason, b.CreatedBy, b.ExpiresAt).Scan(&b.ID, &b.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert ip ban: %w", err)
+	}
+
+	return b, nil
+}
+
+func (r *ModerationRepository) ListActiveIPBans(ctx context.Context) ([]*IPBan, error) {
+	query := `
+		SELECT ` + ipBanColumns + `
+		FROM ip_bans
+		WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query active ip bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []*IPBan
+	for rows.Next() {
+		b, err := scanIPBan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan ip ban: %w", err)
+		}
+		bans = append(bans, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ip bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+// RevokeIPBan lifts an active IP ban and returns it, or nil if it is
+// missing, expired or already lifted
+func (r *ModerationRepository) RevokeIPBan(ctx context.Context, id, revokedBy uuid.UUID) (*IPBan, error) {
+	query := `
+		UPDATE ip_bans
+		SET revoked_at = NOW(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING ` + ipBanColumns
+
+	b, err := scanIPBan(r.db.QueryRowContext(ctx, query, id, revokedBy))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("revoke ip ban: %w", err)
+	}
+
+	return b, nil
+}
+
+// GetStrikeCounter returns the strikes counted against key, or nil if there
+// are none
+func (r *ModerationRepository) GetStrikeCounter(ctx context.Context, key string) (*StrikeCounter, error) {
+	query := `
+		SELECT key, count, updated_at, expires_at
+		FROM profanity_strikes
+		WHERE key = $1
+	`
+
+	var c StrikeCounter
+	err := r.db.QueryRowContext(ctx, query, key).Scan(&c.Key, &c.Count, &c.UpdatedAt, &c.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get strike counter: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (r *ModerationRepository) SaveStrikeCounter(ctx context.Context, c *StrikeCounter) error {
+	query := `
+		INSERT INTO profanity_strikes (key, count, updated_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET count = EXCLUDED.count, updated_at = EXCLUDED.updated_at, expires_at = EXCLUDED.expires_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, c.Key, c.Count, c.UpdatedAt, c.ExpiresAt); err != nil {
+		return fmt.Errorf("save strike counter: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ModerationRepository) DeleteExpiredStrikeCounters(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM profanity_strikes WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("delete expired strike counters: %w", err)
+	}
+
+	return nil
+}
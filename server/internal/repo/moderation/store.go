@@ -0,0 +1,46 @@
+package moderation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ModerationStore persists abuse reports, the sanctions moderators hand out,
+// IP bans and profanity strikes. ModerationRepository implements it on
+// Postgres.
+type ModerationStore interface {
+	CreateReport(ctx context.Context, report *Report) (*Report, error)
+	// GetReport returns nil if no report has the ID
+	GetReport(ctx context.Context, id uuid.UUID) (*Report, error)
+	// ListReports returns a page of reports matching the filter, oldest first,
+	// and the number of reports matching it
+	ListReports(ctx context.Context, filter ReportFilter) ([]*Report, int, error)
+	ListReportsByReporter(ctx context.Context, reporterID uuid.UUID, limit int) ([]*Report, error)
+	// ResolveReport closes an open report. It returns nil if the report is
+	// missing or was already resolved.
+	ResolveReport(ctx context.Context, id uuid.UUID, resolution Resolution) (*Report, error)
+	// ReopenReport undoes ResolveReport when the resolution's action failed
+	ReopenReport(ctx context.Context, id uuid.UUID) error
+
+	CreateSanction(ctx context.Context, sanction *Sanction) (*Sanction, error)
+	// GetActiveSanction returns the user's longest-running active sanction of
+	// the kind, or nil if there is none
+	GetActiveSanction(ctx context.Context, userID uuid.UUID, kind string) (*Sanction, error)
+	// ListActiveSanctions returns the active sanctions of the kind, or of
+	// every kind if it is empty
+	ListActiveSanctions(ctx context.Context, kind string) ([]*Sanction, error)
+	RevokeSanction(ctx context.Context, id, revokedBy uuid.UUID) (*Sanction, error)
+
+	CreateIPBan(ctx context.Context, ban *IPBan) (*IPBan, error)
+	ListActiveIPBans(ctx context.Context) ([]*IPBan, error)
+	RevokeIPBan(ctx context.Context, id, revokedBy uuid.UUID) (*IPBan, error)
+
+	// GetStrikeCounter returns nil if the key has no strikes
+	GetStrikeCounter(ctx context.Context, key string) (*StrikeCounter, error)
+	SaveStrikeCounter(ctx context.Context, counter *StrikeCounter) error
+	// DeleteExpiredStrikeCounters forgets counters whose strikes have all decayed
+	DeleteExpiredStrikeCounters(ctx context.Context) error
+}
+
+var _ ModerationStore = (*ModerationRepository)(nil)
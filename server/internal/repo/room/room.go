@@ -3,11 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Room struct {
@@ -22,6 +26,20 @@ type Room struct {
 	TopicURL         *string    `json:"topic_url,omitempty"`
 	TopicSource      *string    `json:"topic_source,omitempty"`
 	TopicUpdatedAt   *time.Time `json:"topic_updated_at,omitempty"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	Category         *string    `json:"category,omitempty"`
+	Tags             []string   `json:"tags"`
+	Description      *string    `json:"description,omitempty"`
+	Rules            *string    `json:"rules,omitempty"`
+	SlowModeSeconds  int        `json:"slow_mode_seconds"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	// LastSeq is the sequence number of the room's newest persisted message
+	LastSeq int64 `json:"last_seq"`
+}
+
+// IsArchived reports whether the room has expired and been kept read-only
+func (r *Room) IsArchived() bool {
+	return r.ArchivedAt != nil
 }
 
 type Message struct {
@@ -32,7 +50,42 @@ type Message struct {
 	Content   string     `json:"content"`
 	IsSystem  bool       `json:"is_system"`
 	CreatedAt time.Time  `json:"created_at"`
+	// Seq orders messages within a room. It is assigned by the websocket core
+	// when the message is broadcast and increases by one per message.
+	Seq int64 `json:"seq"`
+	// Authenticated is set when UserID came from the sender's token. Only
+	// then can the message be held against the user.
+	Authenticated bool `json:"-"`
+}
+
+// MessageCursor identifies a position in a room's message history
+type MessageCursor struct {
+	Seq int64
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns an opaque, URL-safe representation of the cursor
+func (c MessageCursor) Encode() string {
+	raw := strconv.FormatInt(c.Seq, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
 }
+
+// DecodeMessageCursor parses a cursor produced by MessageCursor.Encode
+func DecodeMessageCursor(s string) (*MessageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || seq <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &MessageCursor{Seq: seq}, nil
+}
+
 type RoomRepository struct {
 	db *sql.DB
 }
@@ -47,17 +100,21 @@ func (r *RoomRepository) CreateRoom(ctx context.Context, room *Room) (*Room, err
 	var query string
 	var err error
 
+	if room.Tags == nil {
+		room.Tags = []string{}
+	}
+
 	if room.IsPinned {
 		// For pinned rooms, we can set a custom expires_at time
 		query = `
-			INSERT INTO rooms (name, creator_id, is_pinned, topic_title, topic_description, topic_url, topic_source, topic_updated_at, expires_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			INSERT INTO rooms (name, creator_id, is_pinned, topic_title, topic_description, topic_url, topic_source, topic_updated_at, expires_at, category, tags)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 			RETURNING id, created_at, expires_at
 		`
 
 		err = r.db.QueryRowContext(
 			ctx, query, room.Name, room.CreatorID, room.IsPinned, room.TopicTitle, room.TopicDescription,
-			room.TopicURL, room.TopicSource, room.TopicUpdatedAt, room.ExpiresAt,
+			room.TopicURL, room.TopicSource, room.TopicUpdatedAt, room.ExpiresAt, room.Category, pq.Array(room.Tags),
 		).Scan(
 			&room.ID,
 			&room.CreatedAt,
@@ -66,11 +123,11 @@ func (r *RoomRepository) CreateRoom(ctx context.Context, room *Room) (*Room, err
 	} else {
 		// Regular rooms get default 24-hour expiration
 		query = `
-			INSERT INTO rooms (name, creator_id)
-			VALUES ($1, $2)
+			INSERT INTO rooms (name, creator_id, category, tags)
+			VALUES ($1, $2, $3, $4)
 			RETURNING id, created_at, expires_at
 		`
-		err = r.db.QueryRowContext(ctx, query, room.Name, room.CreatorID).Scan(
+		err = r.db.QueryRowContext(ctx, query, room.Name, room.CreatorID, room.Category, pq.Array(room.Tags)).Scan(
 			&room.ID,
 			&room.CreatedAt,
 			&room.ExpiresAt,
@@ -84,16 +141,19 @@ func (r *RoomRepository) CreateRoom(ctx context.Context, room *Room) (*Room, err
 	return room, nil
 }
 
-func (r *RoomRepository) GetRoomByID(ctx context.Context, id uuid.UUID) (*Room, error) {
-	query := `
-		SELECT id, name, creator_id, created_at, expires_at, is_pinned,
-					topic_title, topic_description, topic_url, topic_source, topic_updated_at
-		FROM rooms
-		WHERE id = $1 AND expires_at > NOW()
-	`
+// roomColumns is the column list scanned by scanRoom
+const roomColumns = `id, name, creator_id, created_at, expires_at, is_pinned,
+	topic_title, topic_description, topic_url, topic_source, topic_updated_at, archived_at,
+	category, tags, description, rules, slow_mode_seconds, updated_at,
+	(SELECT COALESCE(MAX(seq), 0) FROM messages WHERE messages.room_id = rooms.id)`
 
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRoom(row rowScanner) (*Room, error) {
 	var room Room
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&room.ID,
 		&room.Name,
 		&room.CreatorID,
@@ -105,7 +165,30 @@ func (r *RoomRepository) GetRoomByID(ctx context.Context, id uuid.UUID) (*Room,
 		&room.TopicURL,
 		&room.TopicSource,
 		&room.TopicUpdatedAt,
+		&room.ArchivedAt,
+		&room.Category,
+		pq.Array(&room.Tags),
+		&room.Description,
+		&room.Rules,
+		&room.SlowModeSeconds,
+		&room.UpdatedAt,
+		&room.LastSeq,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &room, nil
+}
+
+func (r *RoomRepository) GetRoomByID(ctx context.Context, id uuid.UUID) (*Room, error) {
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE id = $1 AND expires_at > NOW()
+	`
+
+	room, err := scanRoom(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil // Room not found or expired
@@ -113,13 +196,79 @@ func (r *RoomRepository) GetRoomByID(ctx context.Context, id uuid.UUID) (*Room,
 		return nil, fmt.Errorf("query room by id: %w", err)
 	}
 
-	return &room, nil
+	return room, nil
+}
+
+// GetRoomByIDIncludingArchived returns an active or archived room. Expired
+// rooms that were not archived are treated as gone.
+func (r *RoomRepository) GetRoomByIDIncludingArchived(ctx context.Context, id uuid.UUID) (*Room, error) {
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE id = $1 AND (expires_at > NOW() OR archived_at IS NOT NULL)
+	`
+
+	room, err := scanRoom(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Room not found, expired or purged
+		}
+		return nil, fmt.Errorf("query room by id: %w", err)
+	}
+
+	return room, nil
+}
+
+// RoomSettings holds the creator-editable fields of a room. Nil fields are left unchanged.
+type RoomSettings struct {
+	Name             *string
+	Description      *string
+	Rules            *string
+	TopicTitle       *string
+	TopicDescription *string
+	TopicURL         *string
+	SlowModeSeconds  *int
+}
+
+// UpdateRoomSettings applies the non-nil settings to an active room and
+// returns the updated room, or nil if the room is not active
+func (r *RoomRepository) UpdateRoomSettings(ctx context.Context, id uuid.UUID, settings RoomSettings) (*Room, error) {
+	query := `
+		UPDATE rooms
+		SET name = COALESCE($2, name),
+			description = COALESCE($3, description),
+			rules = COALESCE($4, rules),
+			topic_title = COALESCE($5, topic_title),
+			topic_description = COALESCE($6, topic_description),
+			topic_url = COALESCE($7, topic_url),
+			topic_updated_at = CASE
+				WHEN $5::text IS NOT NULL OR $6::text IS NOT NULL OR $7::text IS NOT NULL THEN NOW()
+				ELSE topic_updated_at
+			END,
+			slow_mode_seconds = COALESCE($8, slow_mode_seconds),
+			updated_at = NOW()
+		WHERE id = $1 AND expires_at > NOW() AND archived_at IS NULL
+		RETURNING ` + roomColumns
+
+	room, err := scanRoom(r.db.QueryRowContext(
+		ctx, query, id,
+		settings.Name, settings.Description, settings.Rules,
+		settings.TopicTitle, settings.TopicDescription, settings.TopicURL,
+		settings.SlowModeSeconds,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Room not found or expired
+		}
+		return nil, fmt.Errorf("update room settings: %w", err)
+	}
+
+	return room, nil
 }
 
 func (r *RoomRepository) GetAllActiveRooms(ctx context.Context) ([]*Room, error) {
 	query := `
-		SELECT id, name, creator_id, created_at, expires_at, is_pinned,
-					topic_title, topic_description, topic_url, topic_source, topic_updated_at
+		SELECT ` + roomColumns + `
 		FROM rooms
 		WHERE expires_at > NOW()
 		ORDER BY is_pinned DESC, created_at DESC
@@ -131,26 +280,171 @@ func (r *RoomRepository) GetAllActiveRooms(ctx context.Context) ([]*Room, error)
 	}
 	defer rows.Close()
 
+	return scanRooms(rows)
+}
+
+// RoomCursor identifies a position in the room listing, which is ordered by
+// (is_pinned, created_at, id) or (created_at, id) descending depending on the RoomSort
+type RoomCursor struct {
+	IsPinned  bool
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns an opaque, URL-safe representation of the cursor
+func (c RoomCursor) Encode() string {
+	pinned := "0"
+	if c.IsPinned {
+		pinned = "1"
+	}
+	raw := pinned + "|" + c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeRoomCursor parses a cursor produced by RoomCursor.Encode
+func DecodeRoomCursor(s string) (*RoomCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || (parts[0] != "0" && parts[0] != "1") {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &RoomCursor{IsPinned: parts[0] == "1", CreatedAt: createdAt, ID: id}, nil
+}
+
+// RoomFilter narrows down the active room listing. Empty fields match everything.
+type RoomFilter struct {
+	Category string
+	Tag      string
+	Search   string
+}
+
+// where builds the WHERE clause shared by the listing and its count
+func (f RoomFilter) where() (string, []any) {
+	conds := []string{"expires_at > NOW()"}
+	var args []any
+
+	if f.Category != "" {
+		args = append(args, f.Category)
+		conds = append(conds, fmt.Sprintf("category = $%d", len(args)))
+	}
+	if f.Tag != "" {
+		args = append(args, f.Tag)
+		conds = append(conds, fmt.Sprintf("$%d = ANY(tags)", len(args)))
+	}
+	if f.Search != "" {
+		args = append(args, "%"+escapeLike(f.Search)+"%")
+		conds = append(conds, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// RoomSort selects the keyset order of the room listing
+type RoomSort int
+
+const (
+	// RoomSortPinnedFirst lists pinned rooms first, then newest first
+	RoomSortPinnedFirst RoomSort = iota
+	// RoomSortNewest lists rooms newest first regardless of pinning
+	RoomSortNewest
+)
+
+// ListActiveRooms returns a page of active rooms matching the filter and the
+// cursor of the next page, or nil if this is the last one
+func (r *RoomRepository) ListActiveRooms(ctx context.Context, filter RoomFilter, sort RoomSort, after *RoomCursor, limit int) ([]*Room, *RoomCursor, error) {
+	where, args := filter.where()
+	orderBy := "is_pinned DESC, created_at DESC, id DESC"
+	if sort == RoomSortNewest {
+		orderBy = "created_at DESC, id DESC"
+	}
+
+	if after != nil {
+		if sort == RoomSortNewest {
+			args = append(args, after.CreatedAt, after.ID)
+			where += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+		} else {
+			args = append(args, after.IsPinned, after.CreatedAt, after.ID)
+			where += fmt.Sprintf(" AND (is_pinned, created_at, id) < ($%d, $%d, $%d)", len(args)-2, len(args)-1, len(args))
+		}
+	}
+
+	// Fetch one extra row to know if there is another page
+	args = append(args, limit+1)
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE ` + where + `
+		ORDER BY ` + orderBy + `
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query rooms page: %w", err)
+	}
+	defer rows.Close()
+
+	rooms, err := scanRooms(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *RoomCursor
+	if len(rooms) > limit {
+		rooms = rooms[:limit]
+		last := rooms[len(rooms)-1]
+		next = &RoomCursor{IsPinned: last.IsPinned, CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return rooms, next, nil
+}
+
+// FilterActiveRooms returns every active room matching the filter. It backs
+// listings that are ranked in memory, which the room limit keeps small.
+func (r *RoomRepository) FilterActiveRooms(ctx context.Context, filter RoomFilter) ([]*Room, error) {
+	where, args := filter.where()
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE ` + where + `
+		ORDER BY is_pinned DESC, created_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query filtered rooms: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRooms(rows)
+}
+
+func scanRooms(rows *sql.Rows) ([]*Room, error) {
 	var rooms []*Room
 	for rows.Next() {
-		var room Room
-		err := rows.Scan(
-			&room.ID,
-			&room.Name,
-			&room.CreatorID,
-			&room.CreatedAt,
-			&room.ExpiresAt,
-			&room.IsPinned,
-			&room.TopicTitle,
-			&room.TopicDescription,
-			&room.TopicURL,
-			&room.TopicSource,
-			&room.TopicUpdatedAt,
-		)
+		room, err := scanRoom(rows)
 		if err != nil {
 			return nil, fmt.Errorf("scan room: %w", err)
 		}
-		rooms = append(rooms, &room)
+		rooms = append(rooms, room)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -160,6 +454,72 @@ func (r *RoomRepository) GetAllActiveRooms(ctx context.Context) ([]*Room, error)
 	return rooms, nil
 }
 
+// RoomActivity summarises recent message traffic in a room
+type RoomActivity struct {
+	MessagesLastHour  int
+	MessagesLast15Min int
+	LastActivityAt    *time.Time
+}
+
+// GetRoomActivity returns message activity for the given rooms. Rooms without
+// any messages are absent from the result.
+func (r *RoomRepository) GetRoomActivity(ctx context.Context, roomIDs []uuid.UUID) (map[uuid.UUID]RoomActivity, error) {
+	activity := make(map[uuid.UUID]RoomActivity, len(roomIDs))
+	if len(roomIDs) == 0 {
+		return activity, nil
+	}
+
+	ids := make([]string, len(roomIDs))
+	for i, id := range roomIDs {
+		ids[i] = id.String()
+	}
+
+	// Each subquery is answered by the (room_id, created_at) index
+	query := `
+		SELECT r.id,
+			(SELECT COUNT(*) FROM messages m WHERE m.room_id = r.id AND m.created_at > NOW() - INTERVAL '1 hour'),
+			(SELECT COUNT(*) FROM messages m WHERE m.room_id = r.id AND m.created_at > NOW() - INTERVAL '15 minutes'),
+			(SELECT MAX(m.created_at) FROM messages m WHERE m.room_id = r.id)
+		FROM unnest($1::uuid[]) AS r(id)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("query room activity: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var a RoomActivity
+		if err := rows.Scan(&id, &a.MessagesLastHour, &a.MessagesLast15Min, &a.LastActivityAt); err != nil {
+			return nil, fmt.Errorf("scan room activity: %w", err)
+		}
+		if a.LastActivityAt != nil {
+			activity[id] = a
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate room activity: %w", err)
+	}
+
+	return activity, nil
+}
+
+// CountFilteredRooms counts every active room matching the filter
+func (r *RoomRepository) CountFilteredRooms(ctx context.Context, filter RoomFilter) (int, error) {
+	where, args := filter.where()
+
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM rooms WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count filtered rooms: %w", err)
+	}
+
+	return count, nil
+}
+
 func (r *RoomRepository) CountActiveRooms(ctx context.Context) (int, error) {
 	var count int
 	query := `
@@ -177,14 +537,14 @@ func (r *RoomRepository) CountActiveRooms(ctx context.Context) (int, error) {
 
 func (r *RoomRepository) CreateMessage(ctx context.Context, msg *Message) (*Message, error) {
 	query := `
-		INSERT INTO messages (room_id, user_id, username, content, is_system)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO messages (room_id, user_id, username, content, is_system, seq, authenticated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at
 	`
 
 	err := r.db.QueryRowContext(
 		ctx, query,
-		msg.RoomID, msg.UserID, msg.Username, msg.Content, msg.IsSystem,
+		msg.RoomID, msg.UserID, msg.Username, msg.Content, msg.IsSystem, msg.Seq, msg.Authenticated,
 	).Scan(&msg.ID, &msg.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("insert message: %w", err)
@@ -193,13 +553,41 @@ func (r *RoomRepository) CreateMessage(ctx context.Context, msg *Message) (*Mess
 	return msg, nil
 }
 
+// CreateMessages inserts a batch of messages with a single multi-row INSERT.
+// Messages keep the created_at and seq they were given by the websocket core.
+func (r *RoomRepository) CreateMessages(ctx context.Context, msgs []*Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	const cols = 8
+	placeholders := make([]string, 0, len(msgs))
+	args := make([]any, 0, len(msgs)*cols)
+	for i, msg := range msgs {
+		n := i * cols
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+		args = append(args, msg.RoomID, msg.UserID, msg.Username, msg.Content, msg.IsSystem, msg.CreatedAt, msg.Seq, msg.Authenticated)
+	}
+
+	query := `
+		INSERT INTO messages (room_id, user_id, username, content, is_system, created_at, seq, authenticated)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert messages: %w", err)
+	}
+
+	return nil
+}
+
 func (r *RoomRepository) GetRoomMessages(ctx context.Context, roomID uuid.UUID, limit int) ([]*Message, error) {
 	query := `
-		SELECT m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at
+		SELECT m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at, m.seq
 		FROM messages m
 		INNER JOIN rooms r ON m.room_id = r.id
-		WHERE m.room_id = $1 AND r.expires_at > NOW()
-		ORDER BY m.created_at DESC
+		WHERE m.room_id = $1 AND (r.expires_at > NOW() OR r.archived_at IS NOT NULL)
+		ORDER BY m.seq DESC
 		LIMIT $2
 	`
 
@@ -220,6 +608,7 @@ func (r *RoomRepository) GetRoomMessages(ctx context.Context, roomID uuid.UUID,
 			&msg.Content,
 			&msg.IsSystem,
 			&msg.CreatedAt,
+			&msg.Seq,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan message: %w", err)
@@ -239,6 +628,184 @@ func (r *RoomRepository) GetRoomMessages(ctx context.Context, roomID uuid.UUID,
 	return messages, nil
 }
 
+// GetRoomMessagesBefore returns up to limit messages older than the cursor in
+// chronological order. A nil cursor starts from the newest message. The extra
+// return value reports whether older messages remain.
+func (r *RoomRepository) GetRoomMessagesBefore(ctx context.Context, roomID uuid.UUID, before *MessageCursor, limit int) ([]*Message, bool, error) {
+	var rows *sql.Rows
+	var err error
+
+	// Fetch one extra row to know if there is another page
+	if before == nil {
+		query := `
+			SELECT m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at, m.seq
+			FROM messages m
+			INNER JOIN rooms r ON m.room_id = r.id
+			WHERE m.room_id = $1 AND (r.expires_at > NOW() OR r.archived_at IS NOT NULL)
+			ORDER BY m.seq DESC
+			LIMIT $2
+		`
+		rows, err = r.db.QueryContext(ctx, query, roomID, limit+1)
+	} else {
+		query := `
+			SELECT m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at, m.seq
+			FROM messages m
+			INNER JOIN rooms r ON m.room_id = r.id
+			WHERE m.room_id = $1 AND (r.expires_at > NOW() OR r.archived_at IS NOT NULL)
+				AND m.seq < $2
+			ORDER BY m.seq DESC
+			LIMIT $3
+		`
+		rows, err = r.db.QueryContext(ctx, query, roomID, before.Seq, limit+1)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("query room messages page: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var msg Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.RoomID,
+			&msg.UserID,
+			&msg.Username,
+			&msg.Content,
+			&msg.IsSystem,
+			&msg.CreatedAt,
+			&msg.Seq,
+		)
+		if err != nil {
+			return nil, false, fmt.Errorf("scan message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("iterate messages: %w", err)
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	// Reverse the messages to get chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, hasMore, nil
+}
+
+// GetRoomMessagesAfter returns up to limit messages with a sequence number
+// greater than afterSeq in order, for clients resuming after a reconnect
+func (r *RoomRepository) GetRoomMessagesAfter(ctx context.Context, roomID uuid.UUID, afterSeq int64, limit int) ([]*Message, error) {
+	query := `
+		SELECT m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at, m.seq
+		FROM messages m
+		INNER JOIN rooms r ON m.room_id = r.id
+		WHERE m.room_id = $1 AND (r.expires_at > NOW() OR r.archived_at IS NOT NULL)
+			AND m.seq > $2
+		ORDER BY m.seq ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, roomID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query room messages after seq: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var msg Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.RoomID,
+			&msg.UserID,
+			&msg.Username,
+			&msg.Content,
+			&msg.IsSystem,
+			&msg.CreatedAt,
+			&msg.Seq,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// StreamRoomMessages calls fn for every message in the room in chronological
+// order. Rows are read one at a time so large rooms are never held in memory.
+func (r *RoomRepository) StreamRoomMessages(ctx context.Context, roomID uuid.UUID, fn func(*Message) error) error {
+	query := `
+		SELECT id, room_id, user_id, username, content, is_system, created_at, seq
+		FROM messages
+		WHERE room_id = $1
+		ORDER BY seq ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, roomID)
+	if err != nil {
+		return fmt.Errorf("query room transcript: %w", err)
+	}
+	defer rows.Close()
+
+	var msg Message
+	for rows.Next() {
+		err := rows.Scan(
+			&msg.ID,
+			&msg.RoomID,
+			&msg.UserID,
+			&msg.Username,
+			&msg.Content,
+			&msg.IsSystem,
+			&msg.CreatedAt,
+			&msg.Seq,
+		)
+		if err != nil {
+			return fmt.Errorf("scan message: %w", err)
+		}
+		if err := fn(&msg); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate messages: %w", err)
+	}
+
+	return nil
+}
+
+// IsRoomParticipant reports whether the user has posted in the room from a
+// signed-in connection. Other messages may carry a user ID the sender chose.
+func (r *RoomRepository) IsRoomParticipant(ctx context.Context, roomID, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM messages
+			WHERE room_id = $1 AND user_id = $2 AND authenticated
+		)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, roomID, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check room participant: %w", err)
+	}
+
+	return exists, nil
+}
+
 func (r *RoomRepository) DeleteExpiredRooms(ctx context.Context) (int, error) {
 	query := `DELETE FROM rooms WHERE expires_at <= NOW()`
 
@@ -255,6 +822,85 @@ func (r *RoomRepository) DeleteExpiredRooms(ctx context.Context) (int, error) {
 	return int(rowsAffected), nil
 }
 
+// ArchiveExpiredRooms marks expired rooms as archived instead of deleting them
+// and returns the IDs of the rooms that were archived
+func (r *RoomRepository) ArchiveExpiredRooms(ctx context.Context) ([]uuid.UUID, error) {
+	query := `
+		UPDATE rooms
+		SET archived_at = NOW()
+		WHERE expires_at <= NOW() AND archived_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("archive expired rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan archived room id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate archived rooms: %w", err)
+	}
+
+	return ids, nil
+}
+
+// PurgeArchivedRooms deletes rooms that have been archived for longer than
+// the retention window. Their messages are removed by the cascade.
+func (r *RoomRepository) PurgeArchivedRooms(ctx context.Context, retention time.Duration) (int, error) {
+	query := `
+		DELETE FROM rooms
+		WHERE archived_at IS NOT NULL AND archived_at <= NOW() - make_interval(secs => $1)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("purge archived rooms: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ExtendRoom pushes expires_at back by the given duration without letting the
+// room live longer than maxLifetime since its creation. It returns nil if the
+// room is not active.
+func (r *RoomRepository) ExtendRoom(ctx context.Context, id uuid.UUID, by, maxLifetime time.Duration) (*time.Time, error) {
+	query := `
+		UPDATE rooms
+		SET expires_at = GREATEST(
+			expires_at,
+			LEAST(expires_at + make_interval(secs => $2), created_at + make_interval(secs => $3))
+		)
+		WHERE id = $1 AND expires_at > NOW() AND archived_at IS NULL
+		RETURNING expires_at
+	`
+
+	var expiresAt time.Time
+	err := r.db.QueryRowContext(ctx, query, id, by.Seconds(), maxLifetime.Seconds()).Scan(&expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Room not found or expired
+		}
+		return nil, fmt.Errorf("extend room: %w", err)
+	}
+
+	return &expiresAt, nil
+}
+
 func (r *RoomRepository) HasActiveRoom(ctx context.Context, userID uuid.UUID) (bool, error) {
 	var count int
 	query := `
@@ -285,3 +931,117 @@ func (r *RoomRepository) CountPinnedRooms(ctx context.Context) (int, error) {
 
 	return count, nil
 }
+
+// DeleteRoom removes the room whether it is active, expired or archived.
+// Its messages are removed by the cascade.
+func (r *RoomRepository) DeleteRoom(ctx context.Context, id uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM rooms WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ExpireRoom sets an active room's expires_at to now so the cleanup job
+// deletes or archives it like any other expired room
+func (r *RoomRepository) ExpireRoom(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE rooms
+		SET expires_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND expires_at > NOW() AND archived_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("expire room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteRoomMessages removes every message in the room and returns how many there were
+func (r *RoomRepository) DeleteRoomMessages(ctx context.Context, roomID uuid.UUID) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM messages WHERE room_id = $1`, roomID)
+	if err != nil {
+		return 0, fmt.Errorf("delete room messages: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// DeleteUserMessages removes every message the user posted in any room
+func (r *RoomRepository) DeleteUserMessages(ctx context.Context, userID uuid.UUID) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM messages WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("delete user messages: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetMessageBySeq looks a message up whether or not its room is still active
+func (r *RoomRepository) GetMessageBySeq(ctx context.Context, roomID uuid.UUID, seq int64) (*Message, error) {
+	query := `
+		SELECT m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at, m.seq, m.authenticated
+		FROM messages m
+		WHERE m.room_id = $1 AND m.seq = $2
+		LIMIT 1
+	`
+
+	var msg Message
+	err := r.db.QueryRowContext(ctx, query, roomID, seq).Scan(
+		&msg.ID,
+		&msg.RoomID,
+		&msg.UserID,
+		&msg.Username,
+		&msg.Content,
+		&msg.IsSystem,
+		&msg.CreatedAt,
+		&msg.Seq,
+		&msg.Authenticated,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query message by seq: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// DeleteMessage removes a single message from the room
+func (r *RoomRepository) DeleteMessage(ctx context.Context, roomID uuid.UUID, seq int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM messages WHERE room_id = $1 AND seq = $2`, roomID, seq)
+	if err != nil {
+		return false, fmt.Errorf("delete message: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoomStore persists chat rooms. RoomRepository implements it on Postgres.
+type RoomStore interface {
+	CreateRoom(ctx context.Context, room *Room) (*Room, error)
+	// GetRoomByID returns nil if the room doesn't exist or has expired
+	GetRoomByID(ctx context.Context, id uuid.UUID) (*Room, error)
+	GetRoomByIDIncludingArchived(ctx context.Context, id uuid.UUID) (*Room, error)
+	GetAllActiveRooms(ctx context.Context) ([]*Room, error)
+	UpdateRoomSettings(ctx context.Context, id uuid.UUID, settings RoomSettings) (*Room, error)
+	ListActiveRooms(ctx context.Context, filter RoomFilter, sort RoomSort, after *RoomCursor, limit int) ([]*Room, *RoomCursor, error)
+	FilterActiveRooms(ctx context.Context, filter RoomFilter) ([]*Room, error)
+	GetRoomActivity(ctx context.Context, roomIDs []uuid.UUID) (map[uuid.UUID]RoomActivity, error)
+	CountFilteredRooms(ctx context.Context, filter RoomFilter) (int, error)
+	CountActiveRooms(ctx context.Context) (int, error)
+	DeleteExpiredRooms(ctx context.Context) (int, error)
+	ArchiveExpiredRooms(ctx context.Context) ([]uuid.UUID, error)
+	PurgeArchivedRooms(ctx context.Context, retention time.Duration) (int, error)
+	ExtendRoom(ctx context.Context, id uuid.UUID, by, maxLifetime time.Duration) (*time.Time, error)
+	HasActiveRoom(ctx context.Context, userID uuid.UUID) (bool, error)
+	CountPinnedRooms(ctx context.Context) (int, error)
+	// DeleteRoom removes the room and its messages, reporting whether it existed
+	DeleteRoom(ctx context.Context, id uuid.UUID) (bool, error)
+	// ExpireRoom ends an active room now, reporting whether it was active
+	ExpireRoom(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// MessageStore persists the messages posted in rooms. RoomRepository
+// implements it on Postgres.
+type MessageStore interface {
+	CreateMessage(ctx context.Context, msg *Message) (*Message, error)
+	CreateMessages(ctx context.Context, msgs []*Message) error
+	GetRoomMessages(ctx context.Context, roomID uuid.UUID, limit int) ([]*Message, error)
+	GetRoomMessagesBefore(ctx context.Context, roomID uuid.UUID, before *MessageCursor, limit int) ([]*Message, bool, error)
+	GetRoomMessagesAfter(ctx context.Context, roomID uuid.UUID, afterSeq int64, limit int) ([]*Message, error)
+	StreamRoomMessages(ctx context.Context, roomID uuid.UUID, fn func(*Message) error) error
+	IsRoomParticipant(ctx context.Context, roomID, userID uuid.UUID) (bool, error)
+	// GetMessageBySeq returns nil if the room has no message with the sequence number
+	GetMessageBySeq(ctx context.Context, roomID uuid.UUID, seq int64) (*Message, error)
+	DeleteMessage(ctx context.Context, roomID uuid.UUID, seq int64) (bool, error)
+	DeleteRoomMessages(ctx context.Context, roomID uuid.UUID) (int, error)
+	DeleteUserMessages(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+var (
+	_ RoomStore    = (*RoomRepository)(nil)
+	_ MessageStore = (*RoomRepository)(nil)
+)
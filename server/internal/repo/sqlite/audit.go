@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+var _ auditRepo.AuditStore = (*AuditRepository)(nil)
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+const auditColumns = `id, actor_id, action, target_type, target_id, reason, before, after, created_at`
+
+func scanAuditEntry(row rowScanner) (*auditRepo.Entry, error) {
+	var e auditRepo.Entry
+	var before, after []byte
+	err := row.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID, &e.Reason, &before, &after, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(before) > 0 {
+		e.Before = json.RawMessage(before)
+	}
+	if len(after) > 0 {
+		e.After = json.RawMessage(after)
+	}
+	return &e, nil
+}
+
+func auditJSONArg(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func (r *AuditRepository) Append(ctx context.Context, e *auditRepo.Entry) (*auditRepo.Entry, error) {
+	query := `
+		INSERT INTO moderation_audit_log (actor_id, action, target_type, target_id, reason, before, after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, e.ActorID, e.Action, e.TargetType, e.TargetID, e.Reason,
+		auditJSONArg(e.Before), auditJSONArg(e.After), now()).
+		Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert audit entry: %w", err)
+	}
+
+	return e, nil
+}
+
+func auditFilterWhere(f auditRepo.Filter) (string, []any) {
+	var args []any
+	conds := []string{"TRUE"}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.ActorID != nil {
+		add("actor_id = $%d", *f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id = $%d", f.TargetID)
+	}
+	if f.Since != nil {
+		add("created_at >= $%d", *utc(f.Since))
+	}
+	if f.Until != nil {
+		add("created_at < $%d", *utc(f.Until))
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func (r *AuditRepository) List(ctx context.Context, f auditRepo.Filter) ([]*auditRepo.Entry, int, error) {
+	where, args := auditFilterWhere(f)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM moderation_audit_log WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count audit entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM moderation_audit_log
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, auditColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*auditRepo.Entry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+func (r *AuditRepository) Export(ctx context.Context, f auditRepo.Filter, fn func(*auditRepo.Entry) error) error {
+	where, args := auditFilterWhere(f)
+
+	query := `
+		SELECT ` + auditColumns + `
+		FROM moderation_audit_log
+		WHERE ` + where + `
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return fmt.Errorf("scan audit entry: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate audit entries: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,421 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+)
+
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+var _ moderationRepo.ModerationStore = (*ModerationRepository)(nil)
+
+func NewModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// reportColumns is the column list scanned by scanReport
+const reportColumns = `id, reporter_id, target_type, target_user_id, room_id, room_name,
+	message_seq, message_username, message_content, message_created_at,
+	reason, details, status, action, resolved_by, resolution_note, resolved_at, created_at`
+
+func scanReport(row rowScanner) (*moderationRepo.Report, error) {
+	var report moderationRepo.Report
+	err := row.Scan(
+		&report.ID,
+		&report.ReporterID,
+		&report.TargetType,
+		&report.TargetUserID,
+		&report.RoomID,
+		&report.RoomName,
+		&report.MessageSeq,
+		&report.MessageUsername,
+		&report.MessageContent,
+		&report.MessageCreatedAt,
+		&report.Reason,
+		&report.Details,
+		&report.Status,
+		&report.Action,
+		&report.ResolvedBy,
+		&report.ResolutionNote,
+		&report.ResolvedAt,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func scanReports(rows *sql.Rows) ([]*moderationRepo.Report, error) {
+	var reports []*moderationRepo.Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+func (r *ModerationRepository) CreateReport(ctx context.Context, report *moderationRepo.Report) (*moderationRepo.Report, error) {
+	query := `
+		INSERT INTO reports (reporter_id, target_type, target_user_id, room_id, room_name,
+			message_seq, message_username, message_content, message_created_at, reason, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, status, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		report.ReporterID, report.TargetType, report.TargetUserID, report.RoomID, report.RoomName,
+		report.MessageSeq, report.MessageUsername, report.MessageContent, utc(report.MessageCreatedAt),
+		report.Reason, report.Details,
+	).Scan(&report.ID, &report.Status, &report.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert report: %w", err)
+	}
+
+	return report, nil
+}
+
+func (r *ModerationRepository) GetReport(ctx context.Context, id uuid.UUID) (*moderationRepo.Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE id = $1`
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query report: %w", err)
+	}
+
+	return report, nil
+}
+
+// reportFilterWhere builds the WHERE clause shared by the queue and its count
+func reportFilterWhere(f moderationRepo.ReportFilter) (string, []any) {
+	var args []any
+	conds := []string{"TRUE"}
+
+	if f.Status != "" {
+		args = append(args, f.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if f.TargetType != "" {
+		args = append(args, f.TargetType)
+		conds = append(conds, fmt.Sprintf("target_type = $%d", len(args)))
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func (r *ModerationRepository) ListReports(ctx context.Context, f moderationRepo.ReportFilter) ([]*moderationRepo.Report, int, error) {
+	where, args := reportFilterWhere(f)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count reports: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM reports
+		WHERE %s
+		ORDER BY created_at ASC, id ASC
+		LIMIT $%d OFFSET $%d
+	`, reportColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports, err := scanReports(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+// ListReportsByReporter returns the user's latest reports, newest first
+func (r *ModerationRepository) ListReportsByReporter(ctx context.Context, reporterID uuid.UUID, limit int) ([]*moderationRepo.Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE reporter_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, reporterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query reports by reporter: %w", err)
+	}
+	defer rows.Close()
+
+	return scanReports(rows)
+}
+
+func (r *ModerationRepository) ResolveReport(ctx context.Context, id uuid.UUID, res moderationRepo.Resolution) (*moderationRepo.Report, error) {
+	query := `
+		UPDATE reports
+		SET status = $2, action = $3, resolved_by = $4, resolution_note = $5, resolved_at = $6
+		WHERE id = $1 AND status = 'open'
+		RETURNING ` + reportColumns
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, id, res.Status, res.Action, res.ResolvedBy, res.Note, now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Report not found or already resolved
+		}
+		return nil, fmt.Errorf("resolve report: %w", err)
+	}
+
+	return report, nil
+}
+
+func (r *ModerationRepository) ReopenReport(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE reports
+		SET status = 'open', action = NULL, resolved_by = NULL, resolution_note = NULL, resolved_at = NULL
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("reopen report: %w", err)
+	}
+
+	return nil
+}
+
+// sanctionColumns is the column list scanned by scanSanction
+const sanctionColumns = `id, user_id, kind, reason, report_id, created_by, expires_at, created_at, revoked_at, revoked_by`
+
+func scanSanction(row rowScanner) (*moderationRepo.Sanction, error) {
+	var s moderationRepo.Sanction
+	err := row.Scan(&s.ID, &s.UserID, &s.Kind, &s.Reason, &s.ReportID, &s.CreatedBy, &s.ExpiresAt, &s.CreatedAt, &s.RevokedAt, &s.RevokedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *ModerationRepository) CreateSanction(ctx context.Context, s *moderationRepo.Sanction) (*moderationRepo.Sanction, error) {
+	query := `
+		INSERT INTO sanctions (user_id, kind, reason, report_id, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, s.UserID, s.Kind, s.Reason, s.ReportID, s.CreatedBy, utc(s.ExpiresAt)).
+		Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert sanction: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *ModerationRepository) GetActiveSanction(ctx context.Context, userID uuid.UUID, kind string) (*moderationRepo.Sanction, error) {
+	query := `
+		SELECT ` + sanctionColumns + `
+		FROM sanctions
+		WHERE user_id = $1 AND kind = $2 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $3)
+		ORDER BY expires_at DESC NULLS FIRST
+		LIMIT 1
+	`
+
+	s, err := scanSanction(r.db.QueryRowContext(ctx, query, userID, kind, now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query active sanction: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *ModerationRepository) ListActiveSanctions(ctx context.Context, kind string) ([]*moderationRepo.Sanction, error) {
+	query := `
+		SELECT ` + sanctionColumns + `
+		FROM sanctions
+		WHERE ($1 = '' OR kind = $1) AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, kind, now())
+	if err != nil {
+		return nil, fmt.Errorf("query active sanctions: %w", err)
+	}
+	defer rows.Close()
+
+	var sanctions []*moderationRepo.Sanction
+	for rows.Next() {
+		s, err := scanSanction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan sanction: %w", err)
+		}
+		sanctions = append(sanctions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sanctions: %w", err)
+	}
+
+	return sanctions, nil
+}
+
+func (r *ModerationRepository) RevokeSanction(ctx context.Context, id, revokedBy uuid.UUID) (*moderationRepo.Sanction, error) {
+	query := `
+		UPDATE sanctions
+		SET revoked_at = $3, revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $3)
+		RETURNING ` + sanctionColumns
+
+	s, err := scanSanction(r.db.QueryRowContext(ctx, query, id, revokedBy, now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("revoke sanction: %w", err)
+	}
+
+	return s, nil
+}
+
+// ipBanColumns is the column list scanned by scanIPBan
+const ipBanColumns = `id, cidr, reason, created_by, expires_at, revoked_at, revoked_by, created_at`
+
+func scanIPBan(row rowScanner) (*moderationRepo.IPBan, error) {
+	var b moderationRepo.IPBan
+	err := row.Scan(&b.ID, &b.CIDR, &b.Reason, &b.CreatedBy, &b.ExpiresAt, &b.RevokedAt, &b.RevokedBy, &b.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func (r *ModerationRepository) CreateIPBan(ctx context.Context, b *moderationRepo.IPBan) (*moderationRepo.IPBan, error) {
+	query := `
+		INSERT INTO ip_bans (cidr, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, b.CIDR, b.Reason, b.CreatedBy, utc(b.ExpiresAt)).Scan(&b.ID, &b.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert ip ban: %w", err)
+	}
+
+	return b, nil
+}
+
+func (r *ModerationRepository) ListActiveIPBans(ctx context.Context) ([]*moderationRepo.IPBan, error) {
+	query := `
+		SELECT ` + ipBanColumns + `
+		FROM ip_bans
+		WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $1)
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now())
+	if err != nil {
+		return nil, fmt.Errorf("query active ip bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []*moderationRepo.IPBan
+	for rows.Next() {
+		b, err := scanIPBan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan ip ban: %w", err)
+		}
+		bans = append(bans, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ip bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+func (r *ModerationRepository) RevokeIPBan(ctx context.Context, id, revokedBy uuid.UUID) (*moderationRepo.IPBan, error) {
+	query := `
+		UPDATE ip_bans
+		SET revoked_at = $3, revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $3)
+		RETURNING ` + ipBanColumns
+
+	b, err := scanIPBan(r.db.QueryRowContext(ctx, query, id, revokedBy, now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("revoke ip ban: %w", err)
+	}
+
+	return b, nil
+}
+
+func (r *ModerationRepository) GetStrikeCounter(ctx context.Context, key string) (*moderationRepo.StrikeCounter, error) {
+	query := `
+		SELECT key, count, updated_at, expires_at
+		FROM profanity_strikes
+		WHERE key = $1
+	`
+
+	var c moderationRepo.StrikeCounter
+	err := r.db.QueryRowContext(ctx, query, key).Scan(&c.Key, &c.Count, &c.UpdatedAt, &c.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get strike counter: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (r *ModerationRepository) SaveStrikeCounter(ctx context.Context, c *moderationRepo.StrikeCounter) error {
+	query := `
+		INSERT INTO profanity_strikes (key, count, updated_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET count = excluded.count, updated_at = excluded.updated_at, expires_at = excluded.expires_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, c.Key, c.Count, c.UpdatedAt.UTC(), c.ExpiresAt.UTC()); err != nil {
+		return fmt.Errorf("save strike counter: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ModerationRepository) DeleteExpiredStrikeCounters(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM profanity_strikes WHERE expires_at <= $1`, now()); err != nil {
+		return fmt.Errorf("delete expired strike counters: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,898 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+)
+
+// RoomRepository stores rooms and their messages. It implements both
+// roomRepo.RoomStore and roomRepo.MessageStore.
+type RoomRepository struct {
+	db *sql.DB
+}
+
+var (
+	_ roomRepo.RoomStore    = (*RoomRepository)(nil)
+	_ roomRepo.MessageStore = (*RoomRepository)(nil)
+)
+
+func NewRoomRepository(db *sql.DB) *RoomRepository {
+	return &RoomRepository{
+		db: db,
+	}
+}
+
+func (r *RoomRepository) CreateRoom(ctx context.Context, room *roomRepo.Room) (*roomRepo.Room, error) {
+	if room.Tags == nil {
+		room.Tags = []string{}
+	}
+	tags, err := json.Marshal(room.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("encode tags: %w", err)
+	}
+
+	if room.IsPinned {
+		// For pinned rooms, we can set a custom expires_at time
+		query := `
+			INSERT INTO rooms (name, creator_id, is_pinned, topic_title, topic_description, topic_url, topic_source, topic_updated_at, expires_at, category, tags)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id, created_at, expires_at, updated_at
+		`
+
+		err = r.db.QueryRowContext(
+			ctx, query, room.Name, room.CreatorID, room.IsPinned, room.TopicTitle, room.TopicDescription,
+			room.TopicURL, room.TopicSource, utc(room.TopicUpdatedAt), room.ExpiresAt.UTC(), room.Category, string(tags),
+		).Scan(
+			&room.ID,
+			&room.CreatedAt,
+			&room.ExpiresAt,
+			&room.UpdatedAt,
+		)
+	} else {
+		// Regular rooms get the 24-hour expires_at column default
+		query := `
+			INSERT INTO rooms (name, creator_id, category, tags)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at, expires_at, updated_at
+		`
+		err = r.db.QueryRowContext(ctx, query, room.Name, room.CreatorID, room.Category, string(tags)).Scan(
+			&room.ID,
+			&room.CreatedAt,
+			&room.ExpiresAt,
+			&room.UpdatedAt,
+		)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("insert room: %w", err)
+	}
+
+	return room, nil
+}
+
+// roomColumns is the column list scanned by scanRoom
+const roomColumns = `id, name, creator_id, created_at, expires_at, is_pinned,
+	topic_title, topic_description, topic_url, topic_source, topic_updated_at, archived_at,
+	category, tags, description, rules, slow_mode_seconds, updated_at,
+	(SELECT COALESCE(MAX(seq), 0) FROM messages WHERE messages.room_id = rooms.id)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRoom(row rowScanner) (*roomRepo.Room, error) {
+	var room roomRepo.Room
+	var tags string
+	err := row.Scan(
+		&room.ID,
+		&room.Name,
+		&room.CreatorID,
+		&room.CreatedAt,
+		&room.ExpiresAt,
+		&room.IsPinned,
+		&room.TopicTitle,
+		&room.TopicDescription,
+		&room.TopicURL,
+		&room.TopicSource,
+		&room.TopicUpdatedAt,
+		&room.ArchivedAt,
+		&room.Category,
+		&tags,
+		&room.Description,
+		&room.Rules,
+		&room.SlowModeSeconds,
+		&room.UpdatedAt,
+		&room.LastSeq,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(tags), &room.Tags); err != nil {
+		return nil, fmt.Errorf("decode tags: %w", err)
+	}
+
+	return &room, nil
+}
+
+func scanRooms(rows *sql.Rows) ([]*roomRepo.Room, error) {
+	var rooms []*roomRepo.Room
+	for rows.Next() {
+		room, err := scanRoom(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan room: %w", err)
+		}
+		rooms = append(rooms, room)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rooms: %w", err)
+	}
+
+	return rooms, nil
+}
+
+func (r *RoomRepository) GetRoomByID(ctx context.Context, id uuid.UUID) (*roomRepo.Room, error) {
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE id = $1 AND expires_at > $2
+	`
+
+	room, err := scanRoom(r.db.QueryRowContext(ctx, query, id, now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Room not found or expired
+		}
+		return nil, fmt.Errorf("query room by id: %w", err)
+	}
+
+	return room, nil
+}
+
+// GetRoomByIDIncludingArchived returns an active or archived room. Expired
+// rooms that were not archived are treated as gone.
+func (r *RoomRepository) GetRoomByIDIncludingArchived(ctx context.Context, id uuid.UUID) (*roomRepo.Room, error) {
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE id = $1 AND (expires_at > $2 OR archived_at IS NOT NULL)
+	`
+
+	room, err := scanRoom(r.db.QueryRowContext(ctx, query, id, now()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Room not found, expired or purged
+		}
+		return nil, fmt.Errorf("query room by id: %w", err)
+	}
+
+	return room, nil
+}
+
+// UpdateRoomSettings applies the non-nil settings to an active room and
+// returns the updated room, or nil if the room is not active
+func (r *RoomRepository) UpdateRoomSettings(ctx context.Context, id uuid.UUID, settings roomRepo.RoomSettings) (*roomRepo.Room, error) {
+	query := `
+		UPDATE rooms
+		SET name = COALESCE($2, name),
+			description = COALESCE($3, description),
+			rules = COALESCE($4, rules),
+			topic_title = COALESCE($5, topic_title),
+			topic_description = COALESCE($6, topic_description),
+			topic_url = COALESCE($7, topic_url),
+			topic_updated_at = CASE
+				WHEN $5 IS NOT NULL OR $6 IS NOT NULL OR $7 IS NOT NULL THEN $9
+				ELSE topic_updated_at
+			END,
+			slow_mode_seconds = COALESCE($8, slow_mode_seconds),
+			updated_at = $9
+		WHERE id = $1 AND expires_at > $9 AND archived_at IS NULL
+		RETURNING ` + roomColumns
+
+	room, err := scanRoom(r.db.QueryRowContext(
+		ctx, query, id,
+		settings.Name, settings.Description, settings.Rules,
+		settings.TopicTitle, settings.TopicDescription, settings.TopicURL,
+		settings.SlowModeSeconds, now(),
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Room not found or expired
+		}
+		return nil, fmt.Errorf("update room settings: %w", err)
+	}
+
+	return room, nil
+}
+
+func (r *RoomRepository) GetAllActiveRooms(ctx context.Context) ([]*roomRepo.Room, error) {
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE expires_at > $1
+		ORDER BY is_pinned DESC, created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now())
+	if err != nil {
+		return nil, fmt.Errorf("query all rooms: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRooms(rows)
+}
+
+// filterWhere builds the WHERE clause shared by the listing and its count.
+// Tags are a JSON array, so the tag filter goes through json_each.
+func filterWhere(f roomRepo.RoomFilter) (string, []any) {
+	args := []any{now()}
+	conds := []string{"expires_at > $1"}
+
+	if f.Category != "" {
+		args = append(args, f.Category)
+		conds = append(conds, fmt.Sprintf("category = $%d", len(args)))
+	}
+	if f.Tag != "" {
+		args = append(args, f.Tag)
+		conds = append(conds, fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(rooms.tags) WHERE json_each.value = $%d)", len(args)))
+	}
+	if f.Search != "" {
+		// LIKE is case-insensitive for ASCII, like ILIKE
+		args = append(args, "%"+escapeLike(f.Search)+"%")
+		conds = append(conds, fmt.Sprintf(`name LIKE $%d ESCAPE '\'`, len(args)))
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// ListActiveRooms returns a page of active rooms matching the filter and the
+// cursor of the next page, or nil if this is the last one
+func (r *RoomRepository) ListActiveRooms(ctx context.Context, filter roomRepo.RoomFilter, sort roomRepo.RoomSort, after *roomRepo.RoomCursor, limit int) ([]*roomRepo.Room, *roomRepo.RoomCursor, error) {
+	where, args := filterWhere(filter)
+	orderBy := "is_pinned DESC, created_at DESC, id DESC"
+	if sort == roomRepo.RoomSortNewest {
+		orderBy = "created_at DESC, id DESC"
+	}
+
+	if after != nil {
+		if sort == roomRepo.RoomSortNewest {
+			args = append(args, after.CreatedAt.UTC(), after.ID)
+			where += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+		} else {
+			args = append(args, after.IsPinned, after.CreatedAt.UTC(), after.ID)
+			where += fmt.Sprintf(" AND (is_pinned, created_at, id) < ($%d, $%d, $%d)", len(args)-2, len(args)-1, len(args))
+		}
+	}
+
+	// Fetch one extra row to know if there is another page
+	args = append(args, limit+1)
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE ` + where + `
+		ORDER BY ` + orderBy + `
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query rooms page: %w", err)
+	}
+	defer rows.Close()
+
+	rooms, err := scanRooms(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *roomRepo.RoomCursor
+	if len(rooms) > limit {
+		rooms = rooms[:limit]
+		last := rooms[len(rooms)-1]
+		next = &roomRepo.RoomCursor{IsPinned: last.IsPinned, CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return rooms, next, nil
+}
+
+// FilterActiveRooms returns every active room matching the filter
+func (r *RoomRepository) FilterActiveRooms(ctx context.Context, filter roomRepo.RoomFilter) ([]*roomRepo.Room, error) {
+	where, args := filterWhere(filter)
+	query := `
+		SELECT ` + roomColumns + `
+		FROM rooms
+		WHERE ` + where + `
+		ORDER BY is_pinned DESC, created_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query filtered rooms: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRooms(rows)
+}
+
+// GetRoomActivity returns message activity for the given rooms. Rooms without
+// any messages are absent from the result.
+func (r *RoomRepository) GetRoomActivity(ctx context.Context, roomIDs []uuid.UUID) (map[uuid.UUID]roomRepo.RoomActivity, error) {
+	activity := make(map[uuid.UUID]roomRepo.RoomActivity, len(roomIDs))
+	if len(roomIDs) == 0 {
+		return activity, nil
+	}
+
+	ids, err := json.Marshal(roomIDs)
+	if err != nil {
+		return nil, fmt.Errorf("encode room ids: %w", err)
+	}
+
+	// Each subquery is answered by the (room_id, created_at) index
+	query := `
+		SELECT r.value,
+			(SELECT COUNT(*) FROM messages m WHERE m.room_id = r.value AND m.created_at > $2),
+			(SELECT COUNT(*) FROM messages m WHERE m.room_id = r.value AND m.created_at > $3),
+			(SELECT MAX(m.created_at) FROM messages m WHERE m.room_id = r.value)
+		FROM json_each($1) AS r
+	`
+
+	current := now()
+	rows, err := r.db.QueryContext(ctx, query, string(ids), current.Add(-time.Hour), current.Add(-15*time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("query room activity: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var a roomRepo.RoomActivity
+		var lastActivity sql.NullString
+		if err := rows.Scan(&id, &a.MessagesLastHour, &a.MessagesLast15Min, &lastActivity); err != nil {
+			return nil, fmt.Errorf("scan room activity: %w", err)
+		}
+		if !lastActivity.Valid {
+			continue
+		}
+		lastActivityAt, err := parseTime(lastActivity.String)
+		if err != nil {
+			return nil, fmt.Errorf("scan room activity: %w", err)
+		}
+		a.LastActivityAt = &lastActivityAt
+		activity[id] = a
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate room activity: %w", err)
+	}
+
+	return activity, nil
+}
+
+// CountFilteredRooms counts every active room matching the filter
+func (r *RoomRepository) CountFilteredRooms(ctx context.Context, filter roomRepo.RoomFilter) (int, error) {
+	where, args := filterWhere(filter)
+
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM rooms WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count filtered rooms: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *RoomRepository) CountActiveRooms(ctx context.Context) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*)
+		FROM rooms
+		WHERE expires_at > $1
+	`
+	err := r.db.QueryRowContext(ctx, query, now()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count active rooms: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *RoomRepository) CreateMessage(ctx context.Context, msg *roomRepo.Message) (*roomRepo.Message, error) {
+	query := `
+		INSERT INTO messages (room_id, user_id, username, content, is_system, seq, authenticated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		msg.RoomID, msg.UserID, msg.Username, msg.Content, msg.IsSystem, msg.Seq, msg.Authenticated,
+	).Scan(&msg.ID, &msg.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert message: %w", err)
+	}
+
+	return msg, nil
+}
+
+// CreateMessages inserts a batch of messages with a single multi-row INSERT.
+// Messages keep the created_at and seq they were given by the websocket core.
+func (r *RoomRepository) CreateMessages(ctx context.Context, msgs []*roomRepo.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	const cols = 8
+	placeholders := make([]string, 0, len(msgs))
+	args := make([]any, 0, len(msgs)*cols)
+	for i, msg := range msgs {
+		n := i * cols
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8))
+		args = append(args, msg.RoomID, msg.UserID, msg.Username, msg.Content, msg.IsSystem, msg.CreatedAt.UTC(), msg.Seq, msg.Authenticated)
+	}
+
+	query := `
+		INSERT INTO messages (room_id, user_id, username, content, is_system, created_at, seq, authenticated)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert messages: %w", err)
+	}
+
+	return nil
+}
+
+const messageColumns = `m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at, m.seq`
+
+func scanMessages(rows *sql.Rows) ([]*roomRepo.Message, error) {
+	var messages []*roomRepo.Message
+	for rows.Next() {
+		var msg roomRepo.Message
+		err := rows.Scan(
+			&msg.ID,
+			&msg.RoomID,
+			&msg.UserID,
+			&msg.Username,
+			&msg.Content,
+			&msg.IsSystem,
+			&msg.CreatedAt,
+			&msg.Seq,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func reverseMessages(messages []*roomRepo.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
+
+func (r *RoomRepository) GetRoomMessages(ctx context.Context, roomID uuid.UUID, limit int) ([]*roomRepo.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		INNER JOIN rooms r ON m.room_id = r.id
+		WHERE m.room_id = $1 AND (r.expires_at > $2 OR r.archived_at IS NOT NULL)
+		ORDER BY m.seq DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, roomID, now(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query room messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reverse the messages to get chronological order
+	reverseMessages(messages)
+	return messages, nil
+}
+
+// GetRoomMessagesBefore returns up to limit messages older than the cursor in
+// chronological order. A nil cursor starts from the newest message. The extra
+// return value reports whether older messages remain.
+func (r *RoomRepository) GetRoomMessagesBefore(ctx context.Context, roomID uuid.UUID, before *roomRepo.MessageCursor, limit int) ([]*roomRepo.Message, bool, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		INNER JOIN rooms r ON m.room_id = r.id
+		WHERE m.room_id = $1 AND (r.expires_at > $2 OR r.archived_at IS NOT NULL)
+			AND ($3 IS NULL OR m.seq < $3)
+		ORDER BY m.seq DESC
+		LIMIT $4
+	`
+
+	var beforeSeq *int64
+	if before != nil {
+		beforeSeq = &before.Seq
+	}
+
+	// Fetch one extra row to know if there is another page
+	rows, err := r.db.QueryContext(ctx, query, roomID, now(), beforeSeq, limit+1)
+	if err != nil {
+		return nil, false, fmt.Errorf("query room messages page: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	// Reverse the messages to get chronological order
+	reverseMessages(messages)
+	return messages, hasMore, nil
+}
+
+// GetRoomMessagesAfter returns up to limit messages with a sequence number
+// greater than afterSeq in order, for clients resuming after a reconnect
+func (r *RoomRepository) GetRoomMessagesAfter(ctx context.Context, roomID uuid.UUID, afterSeq int64, limit int) ([]*roomRepo.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		INNER JOIN rooms r ON m.room_id = r.id
+		WHERE m.room_id = $1 AND (r.expires_at > $2 OR r.archived_at IS NOT NULL)
+			AND m.seq > $3
+		ORDER BY m.seq ASC
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, roomID, now(), afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query room messages after seq: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+// StreamRoomMessages calls fn for every message in the room in chronological
+// order. Rows are read one at a time so large rooms are never held in memory.
+// fn must not query the database: the pool's only connection is busy until
+// the stream ends.
+func (r *RoomRepository) StreamRoomMessages(ctx context.Context, roomID uuid.UUID, fn func(*roomRepo.Message) error) error {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		WHERE m.room_id = $1
+		ORDER BY m.seq ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, roomID)
+	if err != nil {
+		return fmt.Errorf("query room transcript: %w", err)
+	}
+	defer rows.Close()
+
+	var msg roomRepo.Message
+	for rows.Next() {
+		err := rows.Scan(
+			&msg.ID,
+			&msg.RoomID,
+			&msg.UserID,
+			&msg.Username,
+			&msg.Content,
+			&msg.IsSystem,
+			&msg.CreatedAt,
+			&msg.Seq,
+		)
+		if err != nil {
+			return fmt.Errorf("scan message: %w", err)
+		}
+		if err := fn(&msg); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate messages: %w", err)
+	}
+
+	return nil
+}
+
+// IsRoomParticipant reports whether the user has posted in the room from a
+// signed-in connection. Other messages may carry a user ID the sender chose.
+func (r *RoomRepository) IsRoomParticipant(ctx context.Context, roomID, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM messages
+			WHERE room_id = $1 AND user_id = $2 AND authenticated
+		)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, roomID, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check room participant: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *RoomRepository) DeleteExpiredRooms(ctx context.Context) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM rooms WHERE expires_at <= $1`, now())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired rooms: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ArchiveExpiredRooms marks expired rooms as archived instead of deleting them
+// and returns the IDs of the rooms that were archived
+func (r *RoomRepository) ArchiveExpiredRooms(ctx context.Context) ([]uuid.UUID, error) {
+	query := `
+		UPDATE rooms
+		SET archived_at = $1
+		WHERE expires_at <= $1 AND archived_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now())
+	if err != nil {
+		return nil, fmt.Errorf("archive expired rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan archived room id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate archived rooms: %w", err)
+	}
+
+	return ids, nil
+}
+
+// PurgeArchivedRooms deletes rooms that have been archived for longer than
+// the retention window. Their messages are removed by the cascade.
+func (r *RoomRepository) PurgeArchivedRooms(ctx context.Context, retention time.Duration) (int, error) {
+	query := `
+		DELETE FROM rooms
+		WHERE archived_at IS NOT NULL AND archived_at <= $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("purge archived rooms: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ExtendRoom pushes expires_at back by the given duration without letting the
+// room live longer than maxLifetime since its creation. It returns nil if the
+// room is not active. The new expiry is computed in Go inside a transaction
+// since SQLite has no interval arithmetic on stored times.
+func (r *RoomRepository) ExtendRoom(ctx context.Context, id uuid.UUID, by, maxLifetime time.Duration) (*time.Time, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin extend room: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT created_at, expires_at
+		FROM rooms
+		WHERE id = $1 AND expires_at > $2 AND archived_at IS NULL
+	`
+
+	var createdAt, expiresAt time.Time
+	err = tx.QueryRowContext(ctx, query, id, now()).Scan(&createdAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Room not found or expired
+		}
+		return nil, fmt.Errorf("extend room: %w", err)
+	}
+
+	extended := expiresAt.Add(by)
+	if limit := createdAt.Add(maxLifetime); extended.After(limit) {
+		extended = limit
+	}
+	if extended.Before(expiresAt) {
+		extended = expiresAt
+	}
+	extended = extended.UTC()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE rooms SET expires_at = $1 WHERE id = $2`, extended, id); err != nil {
+		return nil, fmt.Errorf("extend room: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit extend room: %w", err)
+	}
+
+	return &extended, nil
+}
+
+func (r *RoomRepository) HasActiveRoom(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int
+	query := `
+		SELECT COUNT(*)
+		FROM rooms
+		WHERE creator_id = $1 AND expires_at > $2
+	`
+	err := r.db.QueryRowContext(ctx, query, userID, now()).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("check active room: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *RoomRepository) CountPinnedRooms(ctx context.Context) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*)
+		FROM rooms
+		WHERE is_pinned = 1 AND expires_at > $1
+	`
+
+	err := r.db.QueryRowContext(ctx, query, now()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count pinned rooms: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteRoom removes the room whether it is active, expired or archived.
+// Its messages are removed by the cascade.
+func (r *RoomRepository) DeleteRoom(ctx context.Context, id uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM rooms WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ExpireRoom sets an active room's expires_at to now so the cleanup job
+// deletes or archives it like any other expired room
+func (r *RoomRepository) ExpireRoom(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE rooms
+		SET expires_at = $2, updated_at = $2
+		WHERE id = $1 AND expires_at > $2 AND archived_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, now())
+	if err != nil {
+		return false, fmt.Errorf("expire room: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// DeleteRoomMessages removes every message in the room and returns how many there were
+func (r *RoomRepository) DeleteRoomMessages(ctx context.Context, roomID uuid.UUID) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM messages WHERE room_id = $1`, roomID)
+	if err != nil {
+		return 0, fmt.Errorf("delete room messages: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// DeleteUserMessages removes every message the user posted in any room
+func (r *RoomRepository) DeleteUserMessages(ctx context.Context, userID uuid.UUID) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM messages WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("delete user messages: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetMessageBySeq looks a message up whether or not its room is still active
+func (r *RoomRepository) GetMessageBySeq(ctx context.Context, roomID uuid.UUID, seq int64) (*roomRepo.Message, error) {
+	query := `
+		SELECT m.id, m.room_id, m.user_id, m.username, m.content, m.is_system, m.created_at, m.seq, m.authenticated
+		FROM messages m
+		WHERE m.room_id = $1 AND m.seq = $2
+		LIMIT 1
+	`
+
+	var msg roomRepo.Message
+	err := r.db.QueryRowContext(ctx, query, roomID, seq).Scan(
+		&msg.ID,
+		&msg.RoomID,
+		&msg.UserID,
+		&msg.Username,
+		&msg.Content,
+		&msg.IsSystem,
+		&msg.CreatedAt,
+		&msg.Seq,
+		&msg.Authenticated,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query message by seq: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// DeleteMessage removes a single message from the room
+func (r *RoomRepository) DeleteMessage(ctx context.Context, roomID uuid.UUID, seq int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM messages WHERE room_id = $1 AND seq = $2`, roomID, seq)
+	if err != nil {
+		return false, fmt.Errorf("delete message: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,53 @@
+// Package sqlite implements the repository stores on a single-file SQLite
+// database for small self-hosted installs. The schema lives in
+// db/migrations/sqlite.
+//
+// SQLite has no timestamp type, so times are stored as UTC text in the format
+// the driver writes with _time_format=sqlite, which sorts chronologically.
+// Every time passed to a query must therefore be in UTC, and "now" is always
+// computed in Go rather than in SQL.
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// timeLayout is the layout the driver uses for times it writes
+const timeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// parseTime parses a time returned by an expression such as MAX(created_at).
+// The driver only converts plain DATETIME columns to time.Time.
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse time %q: %w", s, err)
+	}
+	return t, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,442 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	statsRepo "github.com/momomo0206/go-chat-app/internal/repo/stats"
+)
+
+type StatsRepository struct {
+	db *sql.DB
+}
+
+var _ statsRepo.StatsStore = (*StatsRepository)(nil)
+
+func NewStatsRepository(db *sql.DB) *StatsRepository {
+	return &StatsRepository{
+		db: db,
+	}
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// GetOrCreateUserStats gets existing stats or creates new entry
+func (r *StatsRepository) GetOrCreateUserStats(ctx context.Context, userID uuid.UUID) (*statsRepo.UserStats, error) {
+	return getOrCreateUserStats(ctx, r.db, userID)
+}
+
+// getOrCreateUserStats runs on q so ProcessDailyCheckin can call it inside
+// its transaction. The pool holds a single connection, so a query outside
+// the transaction would wait for it forever.
+func getOrCreateUserStats(ctx context.Context, q queryer, userID uuid.UUID) (*statsRepo.UserStats, error) {
+	if _, err := q.ExecContext(ctx, `INSERT INTO user_stats (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`, userID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT user_id, daily_streak, total_checkins, total_messages,
+			total_upvotes_received, last_checkin_date, last_upvote_given_date,
+			created_at, updated_at
+		FROM user_stats
+		WHERE user_id = $1
+	`
+
+	stats := &statsRepo.UserStats{}
+	err := q.QueryRowContext(ctx, query, userID).Scan(
+		&stats.UserID, &stats.DailyStreak, &stats.TotalCheckins, &stats.TotalMessages,
+		&stats.TotalUpvotesReceived, &stats.LastCheckinDate, &stats.LastUpvoteGivenDate,
+		&stats.CreatedAt, &stats.UpdatedAt,
+	)
+
+	return stats, err
+}
+
+// ProcessDailyCheckin handles daily check-in logic and returns new streak count
+func (r *StatsRepository) ProcessDailyCheckin(ctx context.Context, userID uuid.UUID) (int, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	today := now().Truncate(24 * time.Hour)
+
+	stats, err := getOrCreateUserStats(ctx, tx, userID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	newStreak := 1
+	if stats.LastCheckinDate != nil {
+		lastCheckin := stats.LastCheckinDate.UTC().Truncate(24 * time.Hour)
+		if lastCheckin.Equal(today) {
+			// Already checked in today
+			return stats.DailyStreak, false, nil
+		}
+		if lastCheckin.Equal(today.Add(-24 * time.Hour)) {
+			// Consecutive day
+			newStreak = stats.DailyStreak + 1
+		}
+	}
+
+	updateQuery := `
+		UPDATE user_stats
+		SET daily_streak = $1, total_checkins = total_checkins + 1,
+				last_checkin_date = $2, updated_at = $3
+		WHERE user_id = $4
+	`
+
+	_, err = tx.ExecContext(ctx, updateQuery, newStreak, today, now(), userID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	insertQuery := `
+		INSERT INTO daily_checkins (user_id, checkin_date, streak_count)
+		VALUES ($1, $2, $3)
+	`
+
+	_, err = tx.ExecContext(ctx, insertQuery, userID, today, newStreak)
+	if err != nil {
+		return 0, false, err
+	}
+
+	err = tx.Commit()
+	return newStreak, true, err
+}
+
+// CanUserUpvote checks if user can give an upvote (hasn't given one today and hasn't upvote target)
+func (r *StatsRepository) CanUserUpvote(ctx context.Context, fromUserID, toUserID uuid.UUID) (bool, error) {
+	existsQuery := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM upvotes
+			WHERE from_user_id = $1 AND to_user_id = $2
+		)
+	`
+
+	var alreadyUpvoted bool
+	err := r.db.QueryRowContext(ctx, existsQuery, fromUserID, toUserID).Scan(&alreadyUpvoted)
+	if err != nil {
+		return false, err
+	}
+
+	if alreadyUpvoted {
+		return false, nil
+	}
+
+	today := now().Truncate(24 * time.Hour)
+	todayQuery := `
+		SELECT last_upvote_given_date
+		FROM user_stats
+		WHERE user_id = $1
+	`
+
+	var lastUpvoteDate *time.Time
+	err = r.db.QueryRowContext(ctx, todayQuery, fromUserID).Scan(&lastUpvoteDate)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	if lastUpvoteDate != nil {
+		lastUpvote := lastUpvoteDate.UTC().Truncate(24 * time.Hour)
+		if lastUpvote.Equal(today) {
+			return false, nil // Already gave upvote today
+		}
+	}
+
+	return true, nil
+}
+
+// GiveUpvote processes an upvote between users
+func (r *StatsRepository) GiveUpvote(ctx context.Context, fromUserID, toUserID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updatedAt := now()
+	today := updatedAt.Truncate(24 * time.Hour)
+
+	insertQuery := `
+		INSERT INTO upvotes (from_user_id, to_user_id)
+		VALUES ($1, $2)
+	`
+
+	_, err = tx.ExecContext(ctx, insertQuery, fromUserID, toUserID)
+	if err != nil {
+		return err
+	}
+
+	updateGiverQuery := `
+		UPDATE user_stats
+		SET last_upvote_given_date = $1, updated_at = $2
+		WHERE user_id = $3
+	`
+
+	_, err = tx.ExecContext(ctx, updateGiverQuery, today, updatedAt, fromUserID)
+	if err != nil {
+		return err
+	}
+
+	updateReceiverQuery := `
+		UPDATE user_stats
+		SET total_upvotes_received = total_upvotes_received + 1, updated_at = $1
+		WHERE user_id = $2
+	`
+
+	_, err = tx.ExecContext(ctx, updateReceiverQuery, updatedAt, toUserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUserProfile returns user stats for profile display
+func (r *StatsRepository) GetUserProfile(ctx context.Context, userID uuid.UUID) (*statsRepo.UserStats, error) {
+	return r.GetOrCreateUserStats(ctx, userID)
+}
+
+// IncrementMessageCount increments the user's total message count
+func (r *StatsRepository) IncrementMessageCount(ctx context.Context, userID uuid.UUID) error {
+	return r.IncrementMessageCounts(ctx, map[uuid.UUID]int{userID: 1})
+}
+
+// IncrementMessageCounts adds per-user message counts in a single statement,
+// creating stats rows for users that don't have one yet
+func (r *StatsRepository) IncrementMessageCounts(ctx context.Context, counts map[uuid.UUID]int) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(counts))
+	args := []any{now()}
+	for userID, count := range counts {
+		n := len(args)
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d)", n+1, n+2))
+		args = append(args, userID, count)
+	}
+
+	query := `
+		INSERT INTO user_stats (user_id, total_messages)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+		ON CONFLICT (user_id) DO UPDATE
+		SET total_messages = user_stats.total_messages + excluded.total_messages, updated_at = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// CheckAndAwardAchievements checks if user has earned new achievements and awards them
+func (r *StatsRepository) CheckAndAwardAchievements(ctx context.Context, userID uuid.UUID) ([]statsRepo.Achievement, error) {
+	stats, err := r.GetOrCreateUserStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	achievementTypes, err := r.getAllAchievementTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	earned, err := r.getUserAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	newAchievements := []statsRepo.Achievement{}
+	for _, achType := range achievementTypes {
+		if earned[achType.ID] {
+			continue
+		}
+
+		var currentValue int
+		switch achType.ThresholdType {
+		case "streak":
+			currentValue = stats.DailyStreak
+		case "messages":
+			currentValue = stats.TotalMessages
+		case "upvotes":
+			currentValue = stats.TotalUpvotesReceived
+		default:
+			continue
+		}
+
+		if currentValue >= achType.ThresholdValue {
+			if err := r.awardAchievement(ctx, userID, achType.ID); err != nil {
+				log.Printf("Failed to award achievement %s to user %s: %v", achType.Name, userID.String(), err)
+				continue
+			}
+			newAchievements = append(newAchievements, achType)
+		}
+	}
+
+	return newAchievements, nil
+}
+
+// getAllAchievementTypes gets all available achievement types
+func (r *StatsRepository) getAllAchievementTypes(ctx context.Context) ([]statsRepo.Achievement, error) {
+	query := `
+		SELECT id, name, description, icon, threshold_type, threshold_value
+		FROM achievement_types
+		ORDER BY threshold_value ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var achievements []statsRepo.Achievement
+	for rows.Next() {
+		var ach statsRepo.Achievement
+		err := rows.Scan(&ach.ID, &ach.Name, &ach.Description, &ach.Icon,
+			&ach.ThresholdType, &ach.ThresholdValue)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, ach)
+	}
+
+	return achievements, rows.Err()
+}
+
+// getUserAchievements returns the set of achievement types the user has earned
+func (r *StatsRepository) getUserAchievements(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `
+		SELECT achievement_type_id
+		FROM user_achievements
+		WHERE user_id = $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	earned := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var achID uuid.UUID
+		if err := rows.Scan(&achID); err != nil {
+			return nil, err
+		}
+		earned[achID] = true
+	}
+
+	return earned, rows.Err()
+}
+
+// awardAchievement awards an achievement to a user
+func (r *StatsRepository) awardAchievement(ctx context.Context, userID, achievementID uuid.UUID) error {
+	query := `
+		INSERT INTO user_achievements (user_id, achievement_type_id, earned_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, achievement_type_id) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, achievementID, now())
+	return err
+}
+
+// GetUserAchivementsWithDetails gets user's achievements with full details
+func (r *StatsRepository) GetUserAchivementsWithDetails(ctx context.Context, userID uuid.UUID) ([]statsRepo.Achievement, error) {
+	query := `
+		SELECT at.id, at.name, at.description, at.icon, at.threshold_type, at.threshold_value, ua.earned_at
+		FROM user_achievements ua
+		JOIN achievement_types at ON ua.achievement_type_id = at.id
+		WHERE ua.user_id = $1
+		ORDER BY ua.earned_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var achievements []statsRepo.Achievement
+	for rows.Next() {
+		var ach statsRepo.Achievement
+		err := rows.Scan(&ach.ID, &ach.Name, &ach.Description, &ach.Icon,
+			&ach.ThresholdType, &ach.ThresholdValue, &ach.EarnedAt)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, ach)
+	}
+
+	return achievements, rows.Err()
+}
+
+// GetAchievementTypes returns every achievement type, lowest threshold first
+func (r *StatsRepository) GetAchievementTypes(ctx context.Context) ([]statsRepo.Achievement, error) {
+	achievements, err := r.getAllAchievementTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query achievement types: %w", err)
+	}
+
+	return achievements, nil
+}
+
+// GrantAchievement awards an achievement regardless of the user's stats
+func (r *StatsRepository) GrantAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM achievement_types WHERE id = $1)`, achievementID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("check achievement type: %w", err)
+	}
+	if !exists {
+		return false, statsRepo.ErrAchievementNotFound
+	}
+
+	query := `
+		INSERT INTO user_achievements (user_id, achievement_type_id, earned_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, achievement_type_id) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, achievementID, now())
+	if err != nil {
+		return false, fmt.Errorf("grant achievement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// RevokeAchievement removes an earned achievement from the user
+func (r *StatsRepository) RevokeAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM user_achievements
+		WHERE user_id = $1 AND achievement_type_id = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, achievementID)
+	if err != nil {
+		return false, fmt.Errorf("revoke achievement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
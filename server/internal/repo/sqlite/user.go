@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+var _ userRepo.UserStore = (*UserRepository)(nil)
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*userRepo.User, error) {
+	query := `
+		SELECT id, username, email, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
+
+	var user userRepo.User
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("query user by id: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*userRepo.User, error) {
+	query := `
+		SELECT id, username, email, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE email = $1
+	`
+
+	var user userRepo.User
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("query user by email: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *userRepo.User) (*userRepo.User, error) {
+	query := `
+		INSERT INTO users (username, email, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, role, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx, query,
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+	).Scan(&user.ID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.New("email already exists")
+		}
+
+		return nil, fmt.Errorf("insert user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) CountUsers(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// SearchUsers returns a page of users whose username or email contains
+// search, newest first, and the number of users matching it. An empty search
+// matches everyone.
+func (r *UserRepository) SearchUsers(ctx context.Context, search string, limit, offset int) ([]*userRepo.User, int, error) {
+	pattern := "%" + escapeLike(search) + "%"
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users WHERE username LIKE $1 ESCAPE '\' OR email LIKE $1 ESCAPE '\'`
+	if err := r.db.QueryRowContext(ctx, countQuery, pattern).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count matching users: %w", err)
+	}
+
+	query := `
+		SELECT id, username, email, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE username LIKE $1 ESCAPE '\' OR email LIKE $1 ESCAPE '\'
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*userRepo.User
+	for rows.Next() {
+		var user userRepo.User
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.PasswordHash,
+			&user.Role,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateUsername(ctx context.Context, id uuid.UUID, username string) (*userRepo.User, error) {
+	query := `
+		UPDATE users
+		SET username = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING id, username, email, password_hash, role, created_at, updated_at
+	`
+
+	var user userRepo.User
+	err := r.db.QueryRowContext(ctx, query, username, now(), id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("user not found")
+		}
+		if isUniqueViolation(err) {
+			return nil, errors.New("username already exists")
+		}
+		return nil, fmt.Errorf("update username: %w", err)
+	}
+
+	return &user, nil
+}
+
+// SetUserRole changes the user's role and returns the updated user
+func (r *UserRepository) SetUserRole(ctx context.Context, id uuid.UUID, role string) (*userRepo.User, error) {
+	query := `
+		UPDATE users
+		SET role = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING id, username, email, password_hash, role, created_at, updated_at
+	`
+
+	var user userRepo.User
+	err := r.db.QueryRowContext(ctx, query, role, now(), id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("set user role: %w", err)
+	}
+
+	return &user, nil
+}
@@ -3,7 +3,9 @@ package stats
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -291,6 +293,32 @@ func (r *StatsRepository) IncrementMessageCount(ctx context.Context, userID uuid
 	return nil
 }
 
+// IncrementMessageCounts adds per-user message counts in a single statement,
+// creating stats rows for users that don't have one yet
+func (r *StatsRepository) IncrementMessageCounts(ctx context.Context, counts map[uuid.UUID]int) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(counts))
+	args := make([]any, 0, len(counts)*2)
+	for userID, count := range counts {
+		n := len(args)
+		placeholders = append(placeholders, fmt.Sprintf("($%d::uuid, $%d::int)", n+1, n+2))
+		args = append(args, userID, count)
+	}
+
+	query := `
+		INSERT INTO user_stats (user_id, total_messages)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+		ON CONFLICT (user_id) DO UPDATE
+		SET total_messages = user_stats.total_messages + EXCLUDED.total_messages, updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
 // CheckAndAwardAchievements checks if user has earned new achievements and awards them
 func (r *StatsRepository) CheckAndAwardAchievements(ctx context.Context, userID uuid.UUID) ([]Achievement, error) {
 	// Get user's current stats
@@ -460,3 +488,61 @@ func (r *StatsRepository) GetUserAchivementsWithDetails(ctx context.Context, use
 
 	return achievements, rows.Err()
 }
+
+// GetAchievementTypes returns every achievement type, lowest threshold first
+func (r *StatsRepository) GetAchievementTypes(ctx context.Context) ([]Achievement, error) {
+	achievements, err := r.getAllAchievementTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query achievement types: %w", err)
+	}
+
+	return achievements, nil
+}
+
+// GrantAchievement awards an achievement regardless of the user's stats
+func (r *StatsRepository) GrantAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM achievement_types WHERE id = $1)`, achievementID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("check achievement type: %w", err)
+	}
+	if !exists {
+		return false, ErrAchievementNotFound
+	}
+
+	query := `
+		INSERT INTO user_achievements (user_id, achievement_type_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, achievement_type_id) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, achievementID)
+	if err != nil {
+		return false, fmt.Errorf("grant achievement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// RevokeAchievement removes an earned achievement from the user
+func (r *StatsRepository) RevokeAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM user_achievements
+		WHERE user_id = $1 AND achievement_type_id = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, achievementID)
+	if err != nil {
+		return false, fmt.Errorf("revoke achievement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
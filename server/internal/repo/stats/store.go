@@ -0,0 +1,33 @@
+package stats
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// StatsStore persists user stats, upvotes and achievements. StatsRepository
+// implements it on Postgres.
+type StatsStore interface {
+	GetOrCreateUserStats(ctx context.Context, userID uuid.UUID) (*UserStats, error)
+	// ProcessDailyCheckin returns the new streak and whether this was the first check-in today
+	ProcessDailyCheckin(ctx context.Context, userID uuid.UUID) (int, bool, error)
+	CanUserUpvote(ctx context.Context, fromUserID, toUserID uuid.UUID) (bool, error)
+	GiveUpvote(ctx context.Context, fromUserID, toUserID uuid.UUID) error
+	GetUserProfile(ctx context.Context, userID uuid.UUID) (*UserStats, error)
+	IncrementMessageCount(ctx context.Context, userID uuid.UUID) error
+	IncrementMessageCounts(ctx context.Context, counts map[uuid.UUID]int) error
+	CheckAndAwardAchievements(ctx context.Context, userID uuid.UUID) ([]Achievement, error)
+	GetUserAchivementsWithDetails(ctx context.Context, userID uuid.UUID) ([]Achievement, error)
+	GetAchievementTypes(ctx context.Context) ([]Achievement, error)
+	// GrantAchievement reports false if the user already had the achievement
+	GrantAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error)
+	// RevokeAchievement reports false if the user did not have the achievement
+	RevokeAchievement(ctx context.Context, userID, achievementID uuid.UUID) (bool, error)
+}
+
+// ErrAchievementNotFound is returned when granting an achievement type that does not exist
+var ErrAchievementNotFound = errors.New("achievement not found")
+
+var _ StatsStore = (*StatsRepository)(nil)
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// UserStore persists user accounts. UserRepository implements it on Postgres.
+type UserStore interface {
+	GetUserById(ctx context.Context, id uuid.UUID) (*User, error)
+	// GetUserByEmail returns nil if no user has the email
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	CreateUser(ctx context.Context, user *User) (*User, error)
+	CountUsers(ctx context.Context) (int, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	UpdateUsername(ctx context.Context, id uuid.UUID, username string) (*User, error)
+	SearchUsers(ctx context.Context, search string, limit, offset int) ([]*User, int, error)
+	SetUserRole(ctx context.Context, id uuid.UUID, role string) (*User, error)
+}
+
+var _ UserStore = (*UserRepository)(nil)
@@ -5,17 +5,32 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
+// Roles a user can hold. Moderators and admins can use the moderation and
+// admin APIs.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// IsRole reports whether role is one of the known roles
+func IsRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
 type User struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
 	Email        string    `json:"email"`
 	PasswordHash *string   `json:"-"`
+	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -30,7 +45,7 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*User, error) {
 	query := `
-		SELECT id, username, email, password_hash, created_at, updated_at
+		SELECT id, username, email, password_hash, role, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -41,6 +56,7 @@ func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*User,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -56,7 +72,7 @@ func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*User,
 
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, username, email, password_hash, created_at, updated_at
+		SELECT id, username, email, password_hash, role, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -67,6 +83,7 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*Use
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -84,7 +101,7 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *User) (*User, err
 	query := `
 		INSERT INTO users (username, email, password_hash)
 		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		RETURNING id, role, created_at, updated_at
 	`
 
 	err := r.db.QueryRowContext(
@@ -92,7 +109,7 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *User) (*User, err
 		user.Username,
 		user.Email,
 		user.PasswordHash,
-	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 			return nil, errors.New("email already exists")
@@ -114,6 +131,61 @@ func (r *UserRepository) CountUsers(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// SearchUsers returns a page of users whose username or email contains
+// search, newest first, and the number of users matching it. An empty search
+// matches everyone.
+func (r *UserRepository) SearchUsers(ctx context.Context, search string, limit, offset int) ([]*User, int, error) {
+	pattern := "%" + escapeLike(search) + "%"
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users WHERE username ILIKE $1 OR email ILIKE $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, pattern).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count matching users: %w", err)
+	}
+
+	query := `
+		SELECT id, username, email, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE username ILIKE $1 OR email ILIKE $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		var user User
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.PasswordHash,
+			&user.Role,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
 	if err != nil {
@@ -137,7 +209,7 @@ func (r *UserRepository) UpdateUsername(ctx context.Context, id uuid.UUID, usern
 		UPDATE users
 		SET username = $1, updated_at = NOW()
 		WHERE id = $2
-		RETURNING id, username, email, password_hash, created_at, updated_at
+		RETURNING id, username, email, password_hash, role, created_at, updated_at
 	`
 
 	var user User
@@ -146,6 +218,7 @@ func (r *UserRepository) UpdateUsername(ctx context.Context, id uuid.UUID, usern
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -161,3 +234,32 @@ func (r *UserRepository) UpdateUsername(ctx context.Context, id uuid.UUID, usern
 
 	return &user, nil
 }
+
+// SetUserRole changes the user's role and returns the updated user
+func (r *UserRepository) SetUserRole(ctx context.Context, id uuid.UUID, role string) (*User, error) {
+	query := `
+		UPDATE users
+		SET role = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING id, username, email, password_hash, role, created_at, updated_at
+	`
+
+	var user User
+	err := r.db.QueryRowContext(ctx, query, role, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("set user role: %w", err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+)
+
+// Event is a moderation action to record. Before and After are snapshots of
+// the target around the action and are stored as JSON; either may be nil.
+type Event struct {
+	// ActorID is nil for automatic actions and maintenance commands
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Reason     string
+	Before     any
+	After      any
+}
+
+// AuditService writes and reads the append-only moderation audit log
+type AuditService struct {
+	store auditRepo.AuditStore
+}
+
+func NewAuditService(store auditRepo.AuditStore) *AuditService {
+	return &AuditService{store: store}
+}
+
+// Record appends the event to the log. The action has already been applied
+// by the time it is recorded, so failures are logged instead of returned,
+// and the entry is written even if the request is cancelled meanwhile.
+func (s *AuditService) Record(ctx context.Context, e Event) {
+	entry := &auditRepo.Entry{
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+	}
+	if e.Reason != "" {
+		entry.Reason = &e.Reason
+	}
+
+	var err error
+	if entry.Before, err = snapshot(e.Before); err != nil {
+		log.Printf("Error encoding audit snapshot of %s %s: %v", e.TargetType, e.TargetID, err)
+	}
+	if entry.After, err = snapshot(e.After); err != nil {
+		log.Printf("Error encoding audit snapshot of %s %s: %v", e.TargetType, e.TargetID, err)
+	}
+
+	if _, err := s.store.Append(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("Error recording %s of %s %s in the audit log: %v", e.Action, e.TargetType, e.TargetID, err)
+	}
+}
+
+// snapshot encodes a snapshot, leaving nil and nil pointers empty
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// List returns a page of the log, newest first, and the number of entries
+// matching the filter
+func (s *AuditService) List(ctx context.Context, filter auditRepo.Filter) ([]*auditRepo.Entry, int, error) {
+	entries, total, err := s.store.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if entries == nil {
+		entries = []*auditRepo.Entry{}
+	}
+	return entries, total, nil
+}
+
+// Export writes every entry matching the filter to w as newline-delimited
+// JSON, oldest first
+func (s *AuditService) Export(ctx context.Context, filter auditRepo.Filter, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return s.store.Export(ctx, filter, func(e *auditRepo.Entry) error {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("write audit entry: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,433 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/internal/api/model"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	auditService "github.com/momomo0206/go-chat-app/internal/service/audit"
+	"github.com/momomo0206/go-chat-app/internal/ws"
+)
+
+// ReportReasons are the reasons a report can be filed under
+var ReportReasons = []string{"spam", "harassment", "hate", "sexual", "violence", "impersonation", "other"}
+
+const (
+	maxReportDetailsLen = 1000
+	maxNoteLen          = 1000
+	defaultMuteDuration = 24 * time.Hour
+	maxSanctionDuration = 365 * 24 * time.Hour
+	myReportsLimit      = 50
+)
+
+var (
+	ErrInvalidReport        = errors.New("invalid report")
+	ErrReportTargetNotFound = errors.New("reported message, user or room not found")
+	ErrReportSelf           = errors.New("you can't report yourself")
+	ErrReportNotFound       = errors.New("report not found")
+	ErrReportResolved       = errors.New("report was already resolved")
+	ErrInvalidAction        = errors.New("invalid action")
+	ErrProtectedUser        = errors.New("moderators and admins can't be muted or banned")
+)
+
+type ModerationService struct {
+	store       moderationRepo.ModerationStore
+	userRepo    userRepo.UserStore
+	roomRepo    roomRepo.RoomStore
+	messageRepo roomRepo.MessageStore
+	core        *ws.Core
+	audit       *auditService.AuditService
+
+	accessMu sync.RWMutex
+	access   accessList
+
+	// strikesMu serializes counting strikes, which reads and then writes
+	// the counters in the store
+	strikesMu sync.Mutex
+	strikeCfg strikeConfig
+}
+
+func NewModerationService(store moderationRepo.ModerationStore, users userRepo.UserStore, rooms roomRepo.RoomStore, messages roomRepo.MessageStore, core *ws.Core, audit *auditService.AuditService) *ModerationService {
+	return &ModerationService{
+		store:       store,
+		userRepo:    users,
+		roomRepo:    rooms,
+		messageRepo: messages,
+		core:        core,
+		audit:       audit,
+		strikeCfg:   loadStrikeConfig(),
+	}
+}
+
+// CreateReport files a report. Reported messages and rooms are copied into
+// the report so moderators can still see them after the room expires.
+func (s *ModerationService) CreateReport(ctx context.Context, reporterID uuid.UUID, req model.CreateReportReq) (*moderationRepo.Report, error) {
+	if !slices.Contains(ReportReasons, req.Reason) {
+		return nil, fmt.Errorf("%w: reason must be one of %s", ErrInvalidReport, strings.Join(ReportReasons, ", "))
+	}
+
+	details := strings.TrimSpace(req.Details)
+	if len(details) > maxReportDetailsLen {
+		return nil, fmt.Errorf("%w: details must be at most %d characters", ErrInvalidReport, maxReportDetailsLen)
+	}
+
+	report := &moderationRepo.Report{
+		ReporterID: &reporterID,
+		TargetType: req.TargetType,
+		Reason:     req.Reason,
+	}
+	if details != "" {
+		report.Details = &details
+	}
+
+	var err error
+	switch req.TargetType {
+	case moderationRepo.TargetMessage:
+		err = s.snapshotMessage(ctx, report, req)
+	case moderationRepo.TargetUser:
+		err = s.snapshotUser(ctx, report, req)
+	case moderationRepo.TargetRoom:
+		err = s.snapshotRoom(ctx, report, req)
+	default:
+		err = fmt.Errorf("%w: target_type must be message, user or room", ErrInvalidReport)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if report.TargetUserID != nil && *report.TargetUserID == reporterID {
+		return nil, ErrReportSelf
+	}
+
+	report, err = s.store.CreateReport(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("User %s reported %s (report %s, reason %s)", reporterID, report.TargetType, report.ID, report.Reason)
+	return report, nil
+}
+
+// snapshotMessage copies the reported message into the report. Messages are
+// written to the database in batches, so a message sent a moment ago may
+// only be in the room's live history.
+func (s *ModerationService) snapshotMessage(ctx context.Context, report *moderationRepo.Report, req model.CreateReportReq) error {
+	if req.RoomID == nil || req.MessageSeq <= 0 {
+		return fmt.Errorf("%w: room_id and message_seq are required", ErrInvalidReport)
+	}
+
+	msg, err := s.messageRepo.GetMessageBySeq(ctx, *req.RoomID, req.MessageSeq)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		live, err := s.core.FindMessage(ctx, req.RoomID.String(), req.MessageSeq)
+		if err != nil {
+			return err
+		}
+		if live != nil {
+			msg = liveMessage(*req.RoomID, live)
+		}
+	}
+	if msg == nil {
+		return ErrReportTargetNotFound
+	}
+	if msg.IsSystem {
+		return fmt.Errorf("%w: system messages can't be reported", ErrInvalidReport)
+	}
+
+	// Only messages from signed-in connections are attributed to their user
+	if msg.Authenticated {
+		report.TargetUserID = msg.UserID
+	}
+	report.RoomID = &msg.RoomID
+	report.MessageSeq = &msg.Seq
+	report.MessageUsername = &msg.Username
+	report.MessageContent = &msg.Content
+	report.MessageCreatedAt = &msg.CreatedAt
+
+	return s.snapshotRoomName(ctx, report, msg.RoomID)
+}
+
+// liveMessage converts a message from the core's history to its stored form
+func liveMessage(roomID uuid.UUID, m *ws.Message) *roomRepo.Message {
+	msg := &roomRepo.Message{
+		RoomID:   roomID,
+		Username: m.Username,
+		Content:  m.Content,
+		IsSystem: m.System,
+		Seq:      m.Seq,
+	}
+	if userID, err := uuid.Parse(m.UserID); err == nil {
+		msg.UserID = &userID
+		msg.Authenticated = m.Authenticated
+	}
+	if sentAt, err := time.Parse(time.RFC3339, m.Timestamp); err == nil {
+		msg.CreatedAt = sentAt
+	} else {
+		msg.CreatedAt = time.Now()
+	}
+	return msg
+}
+
+func (s *ModerationService) snapshotUser(ctx context.Context, report *moderationRepo.Report, req model.CreateReportReq) error {
+	if req.UserID == nil {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidReport)
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, *req.UserID)
+	if err != nil {
+		return ErrReportTargetNotFound
+	}
+
+	report.TargetUserID = &user.ID
+	return nil
+}
+
+func (s *ModerationService) snapshotRoom(ctx context.Context, report *moderationRepo.Report, req model.CreateReportReq) error {
+	if req.RoomID == nil {
+		return fmt.Errorf("%w: room_id is required", ErrInvalidReport)
+	}
+
+	room, err := s.roomRepo.GetRoomByIDIncludingArchived(ctx, *req.RoomID)
+	if err != nil {
+		return err
+	}
+	if room == nil {
+		return ErrReportTargetNotFound
+	}
+
+	report.RoomID = &room.ID
+	report.RoomName = &room.Name
+	// Pinned rooms have no creator to act on
+	report.TargetUserID = room.CreatorID
+	return nil
+}
+
+// snapshotRoomName records the name of the room a message was sent in, if
+// the room still exists
+func (s *ModerationService) snapshotRoomName(ctx context.Context, report *moderationRepo.Report, roomID uuid.UUID) error {
+	room, err := s.roomRepo.GetRoomByIDIncludingArchived(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if room != nil {
+		report.RoomName = &room.Name
+	}
+	return nil
+}
+
+// ListReports returns a page of the moderation queue
+func (s *ModerationService) ListReports(ctx context.Context, filter moderationRepo.ReportFilter) ([]*moderationRepo.Report, int, error) {
+	reports, total, err := s.store.ListReports(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if reports == nil {
+		reports = []*moderationRepo.Report{}
+	}
+	return reports, total, nil
+}
+
+func (s *ModerationService) GetReport(ctx context.Context, id uuid.UUID) (*moderationRepo.Report, error) {
+	report, err := s.store.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+// MyReports returns the reporter's latest reports so they can follow them up
+func (s *ModerationService) MyReports(ctx context.Context, reporterID uuid.UUID) ([]*moderationRepo.Report, error) {
+	reports, err := s.store.ListReportsByReporter(ctx, reporterID, myReportsLimit)
+	if err != nil {
+		return nil, err
+	}
+	if reports == nil {
+		reports = []*moderationRepo.Report{}
+	}
+	return reports, nil
+}
+
+// ResolveReport closes the report, applies the moderator's action and lets
+// the reporter know
+func (s *ModerationService) ResolveReport(ctx context.Context, moderatorID, reportID uuid.UUID, req model.ResolveReportReq) (*moderationRepo.Report, error) {
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxNoteLen {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrInvalidAction, maxNoteLen)
+	}
+
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+	if duration < 0 || duration > maxSanctionDuration {
+		return nil, fmt.Errorf("%w: duration_minutes must be between 0 and %d", ErrInvalidAction, int(maxSanctionDuration.Minutes()))
+	}
+
+	report, err := s.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != moderationRepo.StatusOpen {
+		return nil, ErrReportResolved
+	}
+
+	status := moderationRepo.StatusActioned
+	switch req.Action {
+	case moderationRepo.ActionDismiss:
+		status = moderationRepo.StatusDismissed
+	case moderationRepo.ActionDeleteMessage, moderationRepo.ActionMute, moderationRepo.ActionBan, moderationRepo.ActionShadowBan:
+	default:
+		return nil, fmt.Errorf("%w: action must be dismiss, delete_message, mute, ban or shadow_ban", ErrInvalidAction)
+	}
+
+	resolution := moderationRepo.Resolution{
+		Status:     status,
+		Action:     req.Action,
+		ResolvedBy: moderatorID,
+	}
+	if note != "" {
+		resolution.Note = &note
+	}
+
+	// Claim the report before acting on it, so two moderators resolving it
+	// at once can't both apply their action
+	resolved, err := s.store.ResolveReport(ctx, reportID, resolution)
+	if err != nil {
+		return nil, err
+	}
+	if resolved == nil {
+		return nil, ErrReportResolved
+	}
+
+	switch req.Action {
+	case moderationRepo.ActionDeleteMessage:
+		err = s.deleteReportedMessage(ctx, moderatorID, report, note)
+	case moderationRepo.ActionMute:
+		if duration == 0 {
+			duration = defaultMuteDuration
+		}
+		err = s.sanction(ctx, moderatorID, report, moderationRepo.SanctionMute, duration, note)
+	case moderationRepo.ActionBan:
+		err = s.sanction(ctx, moderatorID, report, moderationRepo.SanctionBan, duration, note)
+	case moderationRepo.ActionShadowBan:
+		err = s.sanction(ctx, moderatorID, report, moderationRepo.SanctionShadowBan, duration, note)
+	}
+	if err != nil {
+		// Hand the report back to the queue so it can be resolved another way
+		if reopenErr := s.store.ReopenReport(ctx, reportID); reopenErr != nil {
+			log.Printf("Error reopening report %s after a failed %s: %v", reportID, req.Action, reopenErr)
+		}
+		return nil, err
+	}
+
+	s.audit.Record(ctx, auditService.Event{
+		ActorID:    &moderatorID,
+		Action:     auditRepo.ActionReportResolve,
+		TargetType: auditRepo.TargetReport,
+		TargetID:   reportID.String(),
+		Reason:     note,
+		Before:     report,
+		After:      resolved,
+	})
+	log.Printf("Moderator %s resolved report %s: %s", moderatorID, reportID, req.Action)
+	s.notifyReporter(ctx, resolved)
+
+	return resolved, nil
+}
+
+func (s *ModerationService) deleteReportedMessage(ctx context.Context, moderatorID uuid.UUID, report *moderationRepo.Report, note string) error {
+	if report.TargetType != moderationRepo.TargetMessage {
+		return fmt.Errorf("%w: only message reports can delete a message", ErrInvalidAction)
+	}
+
+	roomID, seq := *report.RoomID, *report.MessageSeq
+	if err := s.core.RemoveMessage(ctx, roomID.String(), seq); err != nil {
+		log.Printf("Error removing message %d from live room %s: %v", seq, roomID, err)
+	}
+
+	// A message sent a moment ago may still be queued for the database
+	if err := s.core.FlushMessages(ctx); err != nil {
+		return err
+	}
+	if _, err := s.messageRepo.DeleteMessage(ctx, roomID, seq); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, auditService.Event{
+		ActorID:    &moderatorID,
+		Action:     auditRepo.ActionMessageDelete,
+		TargetType: auditRepo.TargetMessage,
+		TargetID:   fmt.Sprintf("%s:%d", roomID, seq),
+		Reason:     note,
+		Before: map[string]any{
+			"room_id":    roomID,
+			"seq":        seq,
+			"user_id":    report.TargetUserID,
+			"username":   report.MessageUsername,
+			"content":    report.MessageContent,
+			"created_at": report.MessageCreatedAt,
+		},
+	})
+	return nil
+}
+
+// sanction mutes, bans or shadow-bans the reported user. Message reports
+// only name a user when the message came from a signed-in connection, so the
+// snapshot is trusted even after the message is gone. Without a note the
+// report's reason is recorded as the sanction's reason.
+func (s *ModerationService) sanction(ctx context.Context, moderatorID uuid.UUID, report *moderationRepo.Report, kind string, duration time.Duration, note string) error {
+	if report.TargetUserID == nil {
+		return fmt.Errorf("%w: the report has no registered user to %s", ErrInvalidAction, kind)
+	}
+
+	target, err := s.userRepo.GetUserById(ctx, *report.TargetUserID)
+	if err != nil {
+		return fmt.Errorf("%w: the reported user no longer exists", ErrInvalidAction)
+	}
+
+	reason := note
+	if reason == "" {
+		reason = report.Reason
+	}
+
+	_, err = s.issueSanction(ctx, &moderatorID, target, kind, reason, duration, &report.ID)
+	return err
+}
+
+// notifyReporter tells the reporter's connected clients that their report
+// was handled. Reporters who are offline see it in their report list.
+func (s *ModerationService) notifyReporter(ctx context.Context, report *moderationRepo.Report) {
+	if report.ReporterID == nil {
+		return
+	}
+
+	content := "Thanks for your report. A moderator reviewed it and took action."
+	if report.Status == moderationRepo.StatusDismissed {
+		content = "Thanks for your report. A moderator reviewed it and found no rule was broken."
+	}
+
+	err := s.core.NotifyUser(ctx, report.ReporterID.String(), &ws.Message{
+		Content:   content,
+		Username:  "system",
+		System:    true,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+		Event:     ws.EventReportResolved,
+		Data:      map[string]string{"report_id": report.ID.String(), "status": report.Status},
+	})
+	if err != nil {
+		log.Printf("Error notifying reporter of report %s: %v", report.ID, err)
+	}
+}
@@ -0,0 +1,483 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/internal/api/model"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	auditService "github.com/momomo0206/go-chat-app/internal/service/audit"
+	"github.com/momomo0206/go-chat-app/internal/ws"
+)
+
+const maxReasonLen = 500
+
+var (
+	ErrInvalidSanction  = errors.New("invalid sanction")
+	ErrSanctionNotFound = errors.New("sanction not found or no longer active")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrIPBanNotFound    = errors.New("ip ban not found or no longer active")
+	ErrBanOwnAddress    = errors.New("you can't ban a network that includes your own address")
+)
+
+// BannedError is returned by CheckAccess for banned users and addresses
+type BannedError struct {
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+func (e *BannedError) Error() string {
+	msg := "you are banned"
+	if e.ExpiresAt != nil {
+		msg += " until " + e.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+type ipBanEntry struct {
+	prefix netip.Prefix
+	ban    *moderationRepo.IPBan
+}
+
+// accessList is the snapshot of active bans that CheckAccess reads, so
+// checks on every request never touch the database. It is reloaded after
+// every change; expiry is checked on read.
+type accessList struct {
+	bans   map[uuid.UUID]*moderationRepo.Sanction // user -> longest active ban
+	ipBans []ipBanEntry
+}
+
+// CheckAccess returns a *BannedError if the user or the address is banned.
+// Pass uuid.Nil to check the address alone.
+func (s *ModerationService) CheckAccess(userID uuid.UUID, ip string) error {
+	now := time.Now()
+
+	s.accessMu.RLock()
+	defer s.accessMu.RUnlock()
+
+	if ban, ok := s.access.bans[userID]; ok && ban.IsActive(now) {
+		banned := &BannedError{ExpiresAt: ban.ExpiresAt}
+		if ban.Reason != nil {
+			banned.Reason = *ban.Reason
+		}
+		return banned
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range s.access.ipBans {
+		if entry.ban.IsActive(now) && entry.prefix.Contains(addr) {
+			return &BannedError{Reason: entry.ban.Reason, ExpiresAt: entry.ban.ExpiresAt}
+		}
+	}
+
+	return nil
+}
+
+// reloadAccess rebuilds the access list from the store
+func (s *ModerationService) reloadAccess(ctx context.Context) error {
+	bans, err := s.store.ListActiveSanctions(ctx, moderationRepo.SanctionBan)
+	if err != nil {
+		return err
+	}
+
+	ipBans, err := s.store.ListActiveIPBans(ctx)
+	if err != nil {
+		return err
+	}
+
+	list := accessList{bans: make(map[uuid.UUID]*moderationRepo.Sanction, len(bans))}
+	for _, ban := range bans {
+		if current, ok := list.bans[ban.UserID]; !ok || outlasts(ban, current) {
+			list.bans[ban.UserID] = ban
+		}
+	}
+	for _, ban := range ipBans {
+		prefix, err := netip.ParsePrefix(ban.CIDR)
+		if err != nil {
+			log.Printf("Skipping IP ban %s with invalid CIDR %q: %v", ban.ID, ban.CIDR, err)
+			continue
+		}
+		list.ipBans = append(list.ipBans, ipBanEntry{prefix: prefix, ban: ban})
+	}
+
+	s.accessMu.Lock()
+	s.access = list
+	s.accessMu.Unlock()
+
+	return nil
+}
+
+// outlasts reports whether sanction a ends after b. Sanctions without an
+// expiry outlast everything.
+func outlasts(a, b *moderationRepo.Sanction) bool {
+	if a.ExpiresAt == nil {
+		return b.ExpiresAt != nil
+	}
+	return b.ExpiresAt != nil && a.ExpiresAt.After(*b.ExpiresAt)
+}
+
+// actorName describes who issued a sanction in log lines
+func actorName(actorID *uuid.UUID) string {
+	if actorID == nil {
+		return "Automatic escalation"
+	}
+	return "Moderator " + actorID.String()
+}
+
+// RestoreSanctions loads the active bans and hands the active mutes and
+// shadow-bans to the core after a restart
+func (s *ModerationService) RestoreSanctions(ctx context.Context) error {
+	if err := s.reloadAccess(ctx); err != nil {
+		return err
+	}
+
+	sanctions, err := s.store.ListActiveSanctions(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	restored := 0
+	for _, sanction := range sanctions {
+		if sanction.Kind == moderationRepo.SanctionBan {
+			continue
+		}
+		if err := s.applyToCore(ctx, sanction); err != nil {
+			return err
+		}
+		restored++
+	}
+
+	if restored > 0 {
+		log.Printf("Restored %d active mutes and shadow-bans", restored)
+	}
+	return nil
+}
+
+// sanctionDuration validates the duration of a new sanction. Mutes default
+// to a day, other sanctions without one are permanent.
+func sanctionDuration(kind string, minutes int) (time.Duration, error) {
+	duration := time.Duration(minutes) * time.Minute
+	if duration < 0 || duration > maxSanctionDuration {
+		return 0, fmt.Errorf("duration_minutes must be between 0 and %d", int(maxSanctionDuration.Minutes()))
+	}
+	if duration == 0 && kind == moderationRepo.SanctionMute {
+		duration = defaultMuteDuration
+	}
+	return duration, nil
+}
+
+// CreateSanction mutes, bans or shadow-bans a user outside of a report
+func (s *ModerationService) CreateSanction(ctx context.Context, moderatorID uuid.UUID, req model.CreateSanctionReq) (*moderationRepo.Sanction, error) {
+	switch req.Kind {
+	case moderationRepo.SanctionMute, moderationRepo.SanctionBan, moderationRepo.SanctionShadowBan:
+	default:
+		return nil, fmt.Errorf("%w: kind must be mute, ban or shadow_ban", ErrInvalidSanction)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > maxReasonLen {
+		return nil, fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidSanction, maxReasonLen)
+	}
+
+	duration, err := sanctionDuration(req.Kind, req.DurationMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSanction, err)
+	}
+
+	target, err := s.userRepo.GetUserById(ctx, req.UserID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return s.issueSanction(ctx, &moderatorID, target, req.Kind, reason, duration, nil)
+}
+
+// issueSanction records a sanction against the user and applies it to their
+// live connections. Bans and shadow-bans need a reason, a zero duration
+// never expires. A nil moderator means the sanction was issued automatically.
+func (s *ModerationService) issueSanction(ctx context.Context, moderatorID *uuid.UUID, target *userRepo.User, kind, reason string, duration time.Duration, reportID *uuid.UUID) (*moderationRepo.Sanction, error) {
+	if target.Role != userRepo.RoleUser {
+		return nil, ErrProtectedUser
+	}
+	if reason == "" && kind != moderationRepo.SanctionMute {
+		return nil, fmt.Errorf("%w: a reason is required for a %s", ErrInvalidSanction, strings.ReplaceAll(kind, "_", "-"))
+	}
+
+	sanction := &moderationRepo.Sanction{
+		UserID:    target.ID,
+		Kind:      kind,
+		ReportID:  reportID,
+		CreatedBy: moderatorID,
+	}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		sanction.ExpiresAt = &expiresAt
+	}
+	if reason != "" {
+		sanction.Reason = &reason
+	}
+
+	sanction, err := s.store.CreateSanction(ctx, sanction)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == moderationRepo.SanctionBan {
+		if err := s.reloadAccess(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.applyToCore(ctx, sanction); err != nil {
+		log.Printf("Error applying %s of user %s to live connections: %v", kind, target.ID, err)
+	}
+
+	s.audit.Record(ctx, auditService.Event{
+		ActorID:    moderatorID,
+		Action:     auditRepo.ActionSanctionCreate,
+		TargetType: auditRepo.TargetUser,
+		TargetID:   target.ID.String(),
+		Reason:     reason,
+		Before:     target,
+		After:      sanction,
+	})
+	log.Printf("%s issued a %s to user %s (sanction %s)", actorName(moderatorID), kind, target.ID, sanction.ID)
+	return sanction, nil
+}
+
+// applyToCore enforces a sanction on the user's live connections
+func (s *ModerationService) applyToCore(ctx context.Context, sanction *moderationRepo.Sanction) error {
+	userID := sanction.UserID.String()
+
+	switch sanction.Kind {
+	case moderationRepo.SanctionMute:
+		until := time.Now().Add(maxSanctionDuration)
+		if sanction.ExpiresAt != nil {
+			until = *sanction.ExpiresAt
+		}
+		return s.core.MuteUser(ctx, userID, until)
+	case moderationRepo.SanctionShadowBan:
+		var until time.Time
+		if sanction.ExpiresAt != nil {
+			until = *sanction.ExpiresAt
+		}
+		return s.core.ShadowBanUser(ctx, userID, until)
+	case moderationRepo.SanctionBan:
+		notice := "You have been banned by a moderator"
+		if sanction.CreatedBy == nil {
+			notice = "You have been banned automatically"
+		}
+		if sanction.Reason != nil {
+			notice += ": " + *sanction.Reason
+		}
+		_, err := s.core.DisconnectUser(ctx, userID, notice, ws.CloseBanned, "banned")
+		return err
+	}
+	return nil
+}
+
+// ListSanctions returns the active sanctions of the kind, or of every kind
+// if it is empty
+func (s *ModerationService) ListSanctions(ctx context.Context, kind string) ([]*moderationRepo.Sanction, error) {
+	switch kind {
+	case "", moderationRepo.SanctionMute, moderationRepo.SanctionBan, moderationRepo.SanctionShadowBan:
+	default:
+		return nil, fmt.Errorf("%w: kind must be mute, ban or shadow_ban", ErrInvalidSanction)
+	}
+
+	sanctions, err := s.store.ListActiveSanctions(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	if sanctions == nil {
+		sanctions = []*moderationRepo.Sanction{}
+	}
+	return sanctions, nil
+}
+
+// RevokeSanction lifts an active sanction early. If the user has another
+// active sanction of the same kind, that one stays in force.
+func (s *ModerationService) RevokeSanction(ctx context.Context, moderatorID, sanctionID uuid.UUID) (*moderationRepo.Sanction, error) {
+	sanction, err := s.store.RevokeSanction(ctx, sanctionID, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	if sanction == nil {
+		return nil, ErrSanctionNotFound
+	}
+
+	if sanction.Kind == moderationRepo.SanctionBan {
+		if err := s.reloadAccess(ctx); err != nil {
+			return nil, err
+		}
+	} else if err := s.liftFromCore(ctx, sanction); err != nil {
+		log.Printf("Error lifting %s of user %s from live connections: %v", sanction.Kind, sanction.UserID, err)
+	}
+
+	before := *sanction
+	before.RevokedAt, before.RevokedBy = nil, nil
+	s.audit.Record(ctx, auditService.Event{
+		ActorID:    &moderatorID,
+		Action:     auditRepo.ActionSanctionRevoke,
+		TargetType: auditRepo.TargetUser,
+		TargetID:   sanction.UserID.String(),
+		Before:     &before,
+		After:      sanction,
+	})
+	log.Printf("Moderator %s revoked %s %s of user %s", moderatorID, sanction.Kind, sanction.ID, sanction.UserID)
+	return sanction, nil
+}
+
+// liftFromCore removes a revoked mute or shadow-ban from the core, or
+// replaces it with the user's next active one of the same kind
+func (s *ModerationService) liftFromCore(ctx context.Context, revoked *moderationRepo.Sanction) error {
+	next, err := s.store.GetActiveSanction(ctx, revoked.UserID, revoked.Kind)
+	if err != nil {
+		return err
+	}
+	if next != nil {
+		return s.applyToCore(ctx, next)
+	}
+
+	userID := revoked.UserID.String()
+	if revoked.Kind == moderationRepo.SanctionMute {
+		return s.core.UnmuteUser(ctx, userID)
+	}
+	return s.core.LiftShadowBan(ctx, userID)
+}
+
+// parseNetwork accepts a CIDR or a single address, which becomes a /32 or
+// /128 network
+func parseNetwork(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		if prefix.Addr().Is4In6() {
+			return netip.Prefix{}, errors.New("IPv4-mapped networks are not supported, use the IPv4 form")
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, errors.New("cidr must be an IP address or a CIDR network")
+	}
+	addr = addr.Unmap().WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// CreateIPBan bans a network and disconnects every client connected from it.
+// adminIP is the address of the admin issuing the ban, who can't lock
+// themselves out.
+func (s *ModerationService) CreateIPBan(ctx context.Context, adminID uuid.UUID, adminIP string, req model.CreateIPBanReq) (*moderationRepo.IPBan, error) {
+	prefix, err := parseNetwork(strings.TrimSpace(req.CIDR))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSanction, err)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || len(reason) > maxReasonLen {
+		return nil, fmt.Errorf("%w: reason is required and must be at most %d characters", ErrInvalidSanction, maxReasonLen)
+	}
+
+	duration, err := sanctionDuration(moderationRepo.SanctionBan, req.DurationMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSanction, err)
+	}
+
+	if addr, err := netip.ParseAddr(adminIP); err == nil && prefix.Contains(addr) {
+		return nil, ErrBanOwnAddress
+	}
+
+	return s.issueIPBan(ctx, &adminID, prefix, reason, duration)
+}
+
+// issueIPBan records a ban of the network and disconnects every client
+// connected from it. A nil admin means the ban was issued automatically.
+func (s *ModerationService) issueIPBan(ctx context.Context, adminID *uuid.UUID, prefix netip.Prefix, reason string, duration time.Duration) (*moderationRepo.IPBan, error) {
+	ban := &moderationRepo.IPBan{
+		CIDR:      prefix.String(),
+		Reason:    reason,
+		CreatedBy: adminID,
+	}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	ban, err := s.store.CreateIPBan(ctx, ban)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reloadAccess(ctx); err != nil {
+		return nil, err
+	}
+
+	closed, err := s.core.DisconnectNetwork(ctx, prefix, "Your network has been banned: "+reason, ws.CloseBanned, "banned")
+	if err != nil {
+		log.Printf("Error disconnecting clients from %s: %v", prefix, err)
+	}
+
+	s.audit.Record(ctx, auditService.Event{
+		ActorID:    adminID,
+		Action:     auditRepo.ActionIPBanCreate,
+		TargetType: auditRepo.TargetIPBan,
+		TargetID:   ban.CIDR,
+		Reason:     reason,
+		After:      map[string]any{"ban": ban, "disconnected": closed},
+	})
+	log.Printf("%s banned %s (ban %s), closed %d connections", actorName(adminID), prefix, ban.ID, closed)
+	return ban, nil
+}
+
+func (s *ModerationService) ListIPBans(ctx context.Context) ([]*moderationRepo.IPBan, error) {
+	bans, err := s.store.ListActiveIPBans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bans == nil {
+		bans = []*moderationRepo.IPBan{}
+	}
+	return bans, nil
+}
+
+func (s *ModerationService) RevokeIPBan(ctx context.Context, adminID, banID uuid.UUID) error {
+	ban, err := s.store.RevokeIPBan(ctx, banID, adminID)
+	if err != nil {
+		return err
+	}
+	if ban == nil {
+		return ErrIPBanNotFound
+	}
+
+	if err := s.reloadAccess(ctx); err != nil {
+		return err
+	}
+
+	before := *ban
+	before.RevokedAt, before.RevokedBy = nil, nil
+	s.audit.Record(ctx, auditService.Event{
+		ActorID:    &adminID,
+		Action:     auditRepo.ActionIPBanRevoke,
+		TargetType: auditRepo.TargetIPBan,
+		TargetID:   ban.CIDR,
+		Before:     &before,
+		After:      ban,
+	})
+
+	log.Printf("Admin %s revoked IP ban %s", adminID, banID)
+	return nil
+}
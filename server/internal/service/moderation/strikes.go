@@ -0,0 +1,281 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	"github.com/momomo0206/go-chat-app/internal/ws"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+// Where a profanity strike came from
+const (
+	StrikeUsername    = "username"
+	StrikeDisplayName = "display_name"
+	StrikeRoomName    = "room_name"
+	StrikeRoomTag     = "room_tag"
+	StrikeRoomDetails = "room_details"
+	StrikeRoomTopic   = "room_topic"
+	StrikeMessage     = "message"
+)
+
+// Escalation steps, in order
+const (
+	EscalationWarn = "warn"
+	EscalationMute = "mute"
+	EscalationBan  = "ban"
+)
+
+const strikeTimeout = 5 * time.Second
+
+// strikeConfig sets when repeat offenders are muted and banned. A threshold
+// of 0 turns that step off.
+type strikeConfig struct {
+	muteAt  int
+	banAt   int
+	muteFor time.Duration
+	banFor  time.Duration
+	// decay is how long it takes for one strike to be forgiven
+	decay time.Duration
+	// guestBanAt is when an address is banned. Everyone behind one address
+	// shares its strikes, so it is higher than banAt.
+	guestBanAt int
+}
+
+func loadStrikeConfig() strikeConfig {
+	cfg := strikeConfig{
+		muteAt:     3,
+		banAt:      5,
+		guestBanAt: 10,
+		muteFor:    15 * time.Minute,
+		banFor:     24 * time.Hour,
+		decay:      time.Hour,
+	}
+
+	if n, err := strconv.Atoi(util.GetEnv("PROFANITY_MUTE_STRIKES", "")); err == nil && n >= 0 {
+		cfg.muteAt = n
+	}
+	if n, err := strconv.Atoi(util.GetEnv("PROFANITY_BAN_STRIKES", "")); err == nil && n >= 0 {
+		cfg.banAt = n
+	}
+	if n, err := strconv.Atoi(util.GetEnv("PROFANITY_GUEST_BAN_STRIKES", "")); err == nil && n >= 0 {
+		cfg.guestBanAt = n
+	}
+	if d, err := time.ParseDuration(util.GetEnv("PROFANITY_MUTE_DURATION", "")); err == nil && d > 0 {
+		cfg.muteFor = d
+	}
+	if d, err := time.ParseDuration(util.GetEnv("PROFANITY_BAN_DURATION", "")); err == nil && d > 0 {
+		cfg.banFor = d
+	}
+	if d, err := time.ParseDuration(util.GetEnv("PROFANITY_STRIKE_DECAY", "")); err == nil && d > 0 {
+		cfg.decay = d
+	}
+
+	return cfg
+}
+
+// step returns the escalation step a user reaches at count strikes
+func (cfg strikeConfig) step(count int) string {
+	switch {
+	case cfg.banAt > 0 && count >= cfg.banAt:
+		return EscalationBan
+	case cfg.muteAt > 0 && count >= cfg.muteAt:
+		return EscalationMute
+	default:
+		return EscalationWarn
+	}
+}
+
+// addressStep returns the escalation step an address reaches at count
+// strikes. Addresses can't be muted, so they are only warned until then.
+func (cfg strikeConfig) addressStep(count int) string {
+	if cfg.guestBanAt > 0 && count >= cfg.guestBanAt {
+		return EscalationBan
+	}
+	return EscalationWarn
+}
+
+// Strike is the outcome of a profanity strike
+type Strike struct {
+	Count     int        `json:"strikes"`
+	Action    string     `json:"action"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Notice tells the offender what happened and what comes next
+func (st *Strike) Notice() string {
+	until := ""
+	if st.ExpiresAt != nil {
+		until = " until " + st.ExpiresAt.UTC().Format("2006-01-02 15:04 MST")
+	}
+
+	switch st.Action {
+	case EscalationBan:
+		return "You have been banned" + until + " for repeatedly using inappropriate language"
+	case EscalationMute:
+		return "You have been muted" + until + " for repeatedly using inappropriate language"
+	default:
+		return fmt.Sprintf("Please keep it clean, you have %d strike(s). Repeat offenders are muted and then banned", st.Count)
+	}
+}
+
+// addStrike counts a strike against the key and returns its total. Strikes
+// are forgiven one at a time, a decay interval after the last one. Callers
+// hold strikesMu.
+func (s *ModerationService) addStrike(ctx context.Context, key string, now time.Time) (int, error) {
+	counter, err := s.store.GetStrikeCounter(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if counter == nil {
+		counter = &moderationRepo.StrikeCounter{Key: key, UpdatedAt: now}
+	}
+
+	forgiven := max(int(now.Sub(counter.UpdatedAt)/s.strikeCfg.decay), 0)
+	counter.Count = max(counter.Count-forgiven, 0) + 1
+	counter.UpdatedAt = now
+	counter.ExpiresAt = now.Add(time.Duration(counter.Count) * s.strikeCfg.decay)
+
+	if err := s.store.SaveStrikeCounter(ctx, counter); err != nil {
+		return 0, err
+	}
+	return counter.Count, nil
+}
+
+// RecordProfanityStrike counts content blocked by the profanity filter
+// against the user, if signed in (not uuid.Nil), and against their address.
+// Users are warned, then temporarily muted, then temporarily banned on their
+// own strikes. Addresses are warned until they reach their own, higher, ban
+// threshold, so switching accounts doesn't wipe the slate clean.
+func (s *ModerationService) RecordProfanityStrike(ctx context.Context, userID uuid.UUID, ip, source string) *Strike {
+	now := time.Now()
+
+	s.strikesMu.Lock()
+	userCount, ipCount := 0, 0
+	if userID != uuid.Nil {
+		count, err := s.addStrike(ctx, "user:"+userID.String(), now)
+		if err != nil {
+			log.Printf("Error counting profanity strike of user %s: %v", userID, err)
+		}
+		userCount = count
+	}
+	if ip != "" {
+		count, err := s.addStrike(ctx, "ip:"+ip, now)
+		if err != nil {
+			log.Printf("Error counting profanity strike of %s: %v", ip, err)
+		}
+		ipCount = count
+	}
+	// Forget counters that decayed to nothing so every visitor isn't kept forever
+	if err := s.store.DeleteExpiredStrikeCounters(ctx); err != nil {
+		log.Printf("Error deleting expired profanity strikes: %v", err)
+	}
+	s.strikesMu.Unlock()
+
+	log.Printf("Profanity strike %d for user %s, %d from %s (%s)", userCount, userID, ipCount, ip, source)
+
+	strike := &Strike{Count: ipCount, Action: EscalationWarn}
+	if userID != uuid.Nil {
+		strike = &Strike{Count: userCount, Action: s.strikeCfg.step(userCount)}
+		if strike.Action != EscalationWarn {
+			reason := fmt.Sprintf("automatic: %d profanity strikes, last in %s", userCount, source)
+			s.escalateUser(ctx, strike, userID, reason)
+		}
+	}
+
+	if strike.Action != EscalationBan && s.strikeCfg.addressStep(ipCount) == EscalationBan {
+		addrStrike := &Strike{Count: ipCount, Action: EscalationBan}
+		reason := fmt.Sprintf("automatic: %d profanity strikes from the address, last in %s", ipCount, source)
+		s.escalateAddress(ctx, addrStrike, ip, reason)
+		if addrStrike.Action == EscalationBan {
+			strike.Action, strike.ExpiresAt = EscalationBan, addrStrike.ExpiresAt
+		}
+	}
+	return strike
+}
+
+// escalateUser mutes or bans the user unless they already are. Moderators
+// and admins are only warned.
+func (s *ModerationService) escalateUser(ctx context.Context, strike *Strike, userID uuid.UUID, reason string) {
+	kind, duration := moderationRepo.SanctionMute, s.strikeCfg.muteFor
+	if strike.Action == EscalationBan {
+		kind, duration = moderationRepo.SanctionBan, s.strikeCfg.banFor
+	}
+
+	active, err := s.store.GetActiveSanction(ctx, userID, kind)
+	if err != nil {
+		log.Printf("Error checking active %s of user %s: %v", kind, userID, err)
+		strike.Action = EscalationWarn
+		return
+	}
+	if active != nil {
+		strike.ExpiresAt = active.ExpiresAt
+		return
+	}
+
+	target, err := s.userRepo.GetUserById(ctx, userID)
+	if err != nil || target == nil || target.Role != userRepo.RoleUser {
+		strike.Action = EscalationWarn
+		return
+	}
+
+	sanction, err := s.issueSanction(ctx, nil, target, kind, reason, duration, nil)
+	if err != nil {
+		log.Printf("Error escalating profanity strikes of user %s: %v", userID, err)
+		strike.Action = EscalationWarn
+		return
+	}
+	strike.ExpiresAt = sanction.ExpiresAt
+}
+
+// escalateAddress bans the address unless it already is
+func (s *ModerationService) escalateAddress(ctx context.Context, strike *Strike, ip, reason string) {
+	if err := s.CheckAccess(uuid.Nil, ip); err != nil {
+		return
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		strike.Action = EscalationWarn
+		return
+	}
+
+	ban, err := s.issueIPBan(ctx, nil, netip.PrefixFrom(addr, addr.BitLen()), reason, s.strikeCfg.banFor)
+	if err != nil {
+		log.Printf("Error escalating profanity strikes of %s: %v", ip, err)
+		strike.Action = EscalationWarn
+		return
+	}
+	strike.ExpiresAt = ban.ExpiresAt
+}
+
+// MessageBlocked is told by the core about chat messages the profanity
+// filter dropped. userID is empty unless the connection was authenticated.
+// Warnings are sent to the client, mutes and bans announce themselves.
+func (s *ModerationService) MessageBlocked(clientID, userID, ip string) {
+	ctx, cancel := context.WithTimeout(context.Background(), strikeTimeout)
+	defer cancel()
+
+	uid, _ := uuid.Parse(userID)
+	strike := s.RecordProfanityStrike(ctx, uid, ip, StrikeMessage)
+	if strike.Action != EscalationWarn || clientID == "" {
+		return
+	}
+
+	err := s.core.NotifyClient(ctx, clientID, &ws.Message{
+		Content:   strike.Notice(),
+		Username:  "system",
+		System:    true,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+	})
+	if err != nil {
+		log.Printf("Error warning client %s about a profanity strike: %v", clientID, err)
+	}
+}
@@ -2,7 +2,6 @@ package pinnedrooms
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
 	"time"
@@ -13,15 +12,15 @@ import (
 )
 
 type PinnedRoomsService struct {
-	roomRepo     *roomRepo.RoomRepository
+	roomRepo     roomRepo.RoomStore
 	topicService *topics.TopicService
 	wsCore       *ws.Core
 }
 
-func NewPinnedRoomsService(db *sql.DB, wsCore *ws.Core) *PinnedRoomsService {
+func NewPinnedRoomsService(rooms roomRepo.RoomStore, topicService *topics.TopicService, wsCore *ws.Core) *PinnedRoomsService {
 	return &PinnedRoomsService{
-		roomRepo:     roomRepo.NewRoomRepository(db),
-		topicService: topics.NewTopicService(),
+		roomRepo:     rooms,
+		topicService: topicService,
 		wsCore:       wsCore,
 	}
 }
@@ -72,8 +71,8 @@ func (s *PinnedRoomsService) RefreshPinnedRooms(ctx context.Context) error {
 			continue
 		}
 
-		// Add to Websocket core's in-memory map
-		s.wsCore.Rooms[createdRoom.ID.String()] = &ws.Room{
+		// Make the room live in the websocket core
+		err = s.wsCore.AddRooms(ctx, &ws.Room{
 			ID:               createdRoom.ID.String(),
 			Name:             createdRoom.Name,
 			Clients:          make(map[string]*ws.Client),
@@ -82,6 +81,10 @@ func (s *PinnedRoomsService) RefreshPinnedRooms(ctx context.Context) error {
 			TopicDescription: createdRoom.TopicDescription,
 			TopicURL:         createdRoom.TopicURL,
 			TopicSource:      createdRoom.TopicSource,
+			ExpiresAt:        createdRoom.ExpiresAt,
+		})
+		if err != nil {
+			log.Printf("Failed to load pinned room %s: %v", createdRoom.Name, err)
 		}
 
 		log.Printf("Created pinned room %s with topic %s", createdRoom.Name, topic.Title)
@@ -9,10 +9,10 @@ import (
 )
 
 type StatsService struct {
-	statsRepo *statsRepo.StatsRepository
+	statsRepo statsRepo.StatsStore
 }
 
-func NewStatsService(statsRepo *statsRepo.StatsRepository) *StatsService {
+func NewStatsService(statsRepo statsRepo.StatsStore) *StatsService {
 	return &StatsService{
 		statsRepo: statsRepo,
 	}
@@ -13,6 +13,8 @@ import (
 
 type TopicService struct {
 	client *http.Client
+	// offline skips the external APIs and always serves the fallback topics
+	offline bool
 }
 
 type Topic struct {
@@ -30,6 +32,28 @@ func NewTopicService() *TopicService {
 	}
 }
 
+// NewOfflineTopicService returns a topic service that never calls out to the
+// network, for running the server without internet access
+func NewOfflineTopicService() *TopicService {
+	return &TopicService{offline: true}
+}
+
+// Fallback topics are used when a source can't be reached
+var (
+	fallbackDiscordTopic = Topic{
+		Title:       "Discord discussion",
+		Description: "To see the latest chat",
+		URL:         "https://discord.com",
+		Source:      "Discord",
+	}
+	fallbackHackerNewsTopic = Topic{
+		Title:       "Tech News Discussion",
+		Description: "Discuss today's tech news",
+		URL:         "https://news.ycombinator.com",
+		Source:      "HackerNews",
+	}
+)
+
 func cleanText(text string) string {
 	decoded := html.UnescapeString(text)
 	return strings.TrimSpace(decoded)
@@ -122,17 +146,16 @@ func (s *TopicService) FetchHackerNewsTop(ctx context.Context) (*Topic, error) {
 }
 
 func (s *TopicService) FetchAllTopics(ctx context.Context) ([]Topic, error) {
+	if s.offline {
+		return []Topic{fallbackDiscordTopic, fallbackHackerNewsTopic}, nil
+	}
+
 	topics := make([]Topic, 0, 2)
 
 	dsTopic, err := s.FetchDiscordTopic(ctx)
 	if err != nil {
 		fmt.Printf("Error fetching Discord topic: %v\n", err)
-		topics = append(topics, Topic{
-			Title:       "Discord discussion",
-			Description: "To see the latest chat",
-			URL:         "https://discord.com",
-			Source:      "Discord",
-		})
+		topics = append(topics, fallbackDiscordTopic)
 	} else {
 		topics = append(topics, *dsTopic)
 	}
@@ -140,12 +163,7 @@ func (s *TopicService) FetchAllTopics(ctx context.Context) ([]Topic, error) {
 	hnTopic, err := s.FetchHackerNewsTop(ctx)
 	if err != nil {
 		fmt.Printf("Error fetching HackerNews topic: %v\n", err)
-		topics = append(topics, Topic{
-			Title:       "Tech News Discussion",
-			Description: "Discuss today's tech news",
-			URL:         "https://news.ycombinator.com",
-			Source:      "HackerNews",
-		})
+		topics = append(topics, fallbackHackerNewsTopic)
 	} else {
 		topics = append(topics, *hnTopic)
 	}
@@ -0,0 +1,304 @@
+package transcript
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+)
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+const timeLayout = "2006-01-02 15:04:05 MST"
+
+// ParseFormat maps a format query value to a supported export format
+func ParseFormat(s string) (Format, bool) {
+	switch strings.ToLower(s) {
+	case "", "json":
+		return FormatJSON, true
+	case "ndjson", "jsonl":
+		return FormatNDJSON, true
+	case "markdown", "md":
+		return FormatMarkdown, true
+	case "html":
+		return FormatHTML, true
+	default:
+		return "", false
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatNDJSON:
+		return "ndjson"
+	case FormatMarkdown:
+		return "md"
+	case FormatHTML:
+		return "html"
+	default:
+		return "json"
+	}
+}
+
+// RoomInfo is the transcript header describing the exported room
+type RoomInfo struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// Entry is a single transcript line
+type Entry struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content"`
+	System    bool      `json:"system"`
+	Timestamp time.Time `json:"timestamp"`
+	Seq       int64     `json:"seq"`
+}
+
+type TranscriptService struct {
+	messageRepo roomRepo.MessageStore
+}
+
+func NewTranscriptService(messages roomRepo.MessageStore) *TranscriptService {
+	return &TranscriptService{
+		messageRepo: messages,
+	}
+}
+
+// Export streams the full transcript of the room to w in the given format
+func (s *TranscriptService) Export(ctx context.Context, room *roomRepo.Room, format Format, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := newEncoder(format, bw)
+
+	info := RoomInfo{
+		ID:         room.ID.String(),
+		Name:       room.Name,
+		CreatedAt:  room.CreatedAt,
+		ExpiresAt:  room.ExpiresAt,
+		ExportedAt: time.Now().UTC(),
+	}
+	if err := enc.begin(info); err != nil {
+		return fmt.Errorf("write transcript header: %w", err)
+	}
+
+	err := s.messageRepo.StreamRoomMessages(ctx, room.ID, func(msg *roomRepo.Message) error {
+		entry := Entry{
+			ID:        msg.ID.String(),
+			Username:  msg.Username,
+			Content:   msg.Content,
+			System:    msg.IsSystem,
+			Timestamp: msg.CreatedAt,
+			Seq:       msg.Seq,
+		}
+		if msg.UserID != nil {
+			entry.UserID = msg.UserID.String()
+		}
+		return enc.entry(entry)
+	})
+	if err != nil {
+		return fmt.Errorf("stream transcript: %w", err)
+	}
+
+	if err := enc.end(); err != nil {
+		return fmt.Errorf("write transcript footer: %w", err)
+	}
+
+	return bw.Flush()
+}
+
+type encoder interface {
+	begin(info RoomInfo) error
+	entry(e Entry) error
+	end() error
+}
+
+func newEncoder(format Format, w *bufio.Writer) encoder {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}
+	case FormatMarkdown:
+		return &markdownEncoder{w: w}
+	case FormatHTML:
+		return &htmlEncoder{w: w}
+	default:
+		return &jsonEncoder{w: w}
+	}
+}
+
+// jsonEncoder writes a single JSON document, emitting the messages array
+// element by element so the whole transcript never has to be buffered
+type jsonEncoder struct {
+	w     *bufio.Writer
+	count int
+}
+
+func (e *jsonEncoder) begin(info RoomInfo) error {
+	header, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "{\"room\":%s,\"messages\":[", header)
+	return err
+}
+
+func (e *jsonEncoder) entry(entry Entry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if err := e.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) end() error {
+	_, err := e.w.WriteString("]}\n")
+	return err
+}
+
+// ndjsonEncoder writes the room header followed by one message per line
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin(info RoomInfo) error {
+	return e.enc.Encode(struct {
+		Type string `json:"type"`
+		RoomInfo
+	}{Type: "room", RoomInfo: info})
+}
+
+func (e *ndjsonEncoder) entry(entry Entry) error {
+	return e.enc.Encode(struct {
+		Type string `json:"type"`
+		Entry
+	}{Type: "message", Entry: entry})
+}
+
+func (e *ndjsonEncoder) end() error {
+	return nil
+}
+
+type markdownEncoder struct {
+	w *bufio.Writer
+}
+
+// markdownEscaper backslash-escapes the characters Markdown could read as
+// formatting, links or HTML, so chat text is written as typed
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`,
+	`[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`, `#`, `\#`, `+`, `\+`,
+	`-`, `\-`, `.`, `\.`, `!`, `\!`, `|`, `\|`, `<`, `\<`, `>`, `\>`,
+	`~`, `\~`, `&`, `\&`,
+)
+
+func (e *markdownEncoder) begin(info RoomInfo) error {
+	_, err := fmt.Fprintf(e.w, "# %s\n\n- Room ID: `%s`\n- Created: %s\n- Expires: %s\n- Exported: %s\n\n---\n\n",
+		markdownEscaper.Replace(info.Name), info.ID,
+		info.CreatedAt.UTC().Format(timeLayout),
+		info.ExpiresAt.UTC().Format(timeLayout),
+		info.ExportedAt.Format(timeLayout),
+	)
+	return err
+}
+
+func (e *markdownEncoder) entry(entry Entry) error {
+	ts := entry.Timestamp.UTC().Format(timeLayout)
+	// Keep multi-line messages inside their list item
+	content := strings.ReplaceAll(markdownEscaper.Replace(entry.Content), "\n", "\n  ")
+
+	if entry.System {
+		_, err := fmt.Fprintf(e.w, "- `%s` _%s_\n", ts, content)
+		return err
+	}
+	_, err := fmt.Fprintf(e.w, "- `%s` **%s**: %s\n", ts, markdownEscaper.Replace(entry.Username), content)
+	return err
+}
+
+func (e *markdownEncoder) end() error {
+	return nil
+}
+
+type htmlEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *htmlEncoder) begin(info RoomInfo) error {
+	name := html.EscapeString(info.Name)
+	_, err := fmt.Fprintf(e.w, `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; }
+.meta, time { color: #666; font-size: 0.85em; }
+.system { color: #666; font-style: italic; }
+li { margin: 0.25rem 0; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p class="meta">Created %s &middot; Expires %s &middot; Exported %s</p>
+<ul>
+`, name, name,
+		info.CreatedAt.UTC().Format(timeLayout),
+		info.ExpiresAt.UTC().Format(timeLayout),
+		info.ExportedAt.Format(timeLayout),
+	)
+	return err
+}
+
+func (e *htmlEncoder) entry(entry Entry) error {
+	ts := entry.Timestamp.UTC()
+	if entry.System {
+		_, err := fmt.Fprintf(e.w, "<li class=\"system\"><time datetime=\"%s\">%s</time> %s</li>\n",
+			ts.Format(time.RFC3339), ts.Format(timeLayout), html.EscapeString(entry.Content))
+		return err
+	}
+	_, err := fmt.Fprintf(e.w, "<li><time datetime=\"%s\">%s</time> <strong>%s</strong>: %s</li>\n",
+		ts.Format(time.RFC3339), ts.Format(timeLayout),
+		html.EscapeString(entry.Username), html.EscapeString(entry.Content))
+	return err
+}
+
+func (e *htmlEncoder) end() error {
+	_, err := e.w.WriteString("</ul>\n</body>\n</html>\n")
+	return err
+}
@@ -20,19 +20,36 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
+// AccessChecker rejects banned users and addresses
+type AccessChecker interface {
+	CheckAccess(userID uuid.UUID, ip string) error
+}
+
 type UserService struct {
-	userRepo *repo.UserRepository
+	userRepo repo.UserStore
+	access   AccessChecker
 	timeout  time.Duration
 }
 
-func NewUserService(userRepo *repo.UserRepository) *UserService {
+// NewUserService creates the service. access may be nil for maintenance
+// commands that don't serve clients.
+func NewUserService(userRepo repo.UserStore, access AccessChecker) *UserService {
 	return &UserService{
 		userRepo: userRepo,
+		access:   access,
 		timeout:  time.Duration(2) * time.Second,
 	}
 }
 
-func (s *UserService) CreateUser(ctx context.Context, req model.RequestCreateUser) (*model.ResponseLoginUser, error) {
+func (s *UserService) checkAccess(userID uuid.UUID, ip string) error {
+	if s.access == nil {
+		return nil
+	}
+	return s.access.CheckAccess(userID, ip)
+}
+
+// CreateUser signs up a user from ip. Banned networks can't sign up.
+func (s *UserService) CreateUser(ctx context.Context, req model.RequestCreateUser, ip string) (*model.ResponseLoginUser, error) {
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
@@ -48,6 +65,11 @@ func (s *UserService) CreateUser(ctx context.Context, req model.RequestCreateUse
 		return nil, fmt.Errorf("password must be at least 6 characters")
 	}
 
+	if err := s.checkAccess(uuid.Nil, ip); err != nil {
+		log.Printf("UserService.CreateUser - Signup blocked from banned address %s", ip)
+		return nil, err
+	}
+
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
 		log.Printf("UserService.CreateUser - Password hashing failed: %v", err)
@@ -93,7 +115,9 @@ func (s *UserService) CreateUser(ctx context.Context, req model.RequestCreateUse
 	}, nil
 }
 
-func (s *UserService) Login(ctx context.Context, req model.RequestLoginUser) (*model.ResponseLoginUser, error) {
+// Login checks the user's credentials and issues a token, unless the user or
+// ip is banned
+func (s *UserService) Login(ctx context.Context, req model.RequestLoginUser, ip string) (*model.ResponseLoginUser, error) {
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
@@ -123,6 +147,11 @@ func (s *UserService) Login(ctx context.Context, req model.RequestLoginUser) (*m
 
 	log.Printf("UserService.Login - Password verified successfully for user: %s", user.ID.String())
 
+	if err := s.checkAccess(user.ID, ip); err != nil {
+		log.Printf("UserService.Login - Login blocked for banned user or address: %s (%s)", user.ID.String(), ip)
+		return nil, err
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
 		ID:       user.ID.String(),
 		Username: user.Username,
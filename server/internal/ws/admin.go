@@ -0,0 +1,196 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+)
+
+const (
+	// CloseRoomDeleted is sent to clients when an admin deletes their room
+	CloseRoomDeleted = 4001
+	// CloseAccountDeleted is sent to clients of a user an admin deleted
+	CloseAccountDeleted = 4003
+)
+
+// RoomConnections is the number of clients connected to one live room
+type RoomConnections struct {
+	RoomID  string `json:"room_id"`
+	Name    string `json:"name"`
+	Clients int    `json:"clients"`
+}
+
+// Connections is a snapshot of every live room and its connected clients
+type Connections struct {
+	Rooms        []RoomConnections `json:"rooms"`
+	TotalClients int               `json:"total_clients"`
+	Draining     bool              `json:"draining"`
+}
+
+// ClientInfo identifies a client connected to a room
+type ClientInfo struct {
+	ID       string
+	Username string
+}
+
+// adminRequest runs fn on the core goroutine and closes done when it returns
+type adminRequest struct {
+	fn   func()
+	done chan struct{}
+}
+
+// do runs fn on the core goroutine, so it can touch the room registry
+// safely, and waits for it to finish
+func (c *Core) do(ctx context.Context, fn func()) error {
+	req := adminRequest{fn: fn, done: make(chan struct{})}
+
+	select {
+	case c.admin <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Once accepted the request always runs, so only the caller stops waiting
+	select {
+	case <-req.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// AddRooms makes the rooms live, skipping those that already are
+func (c *Core) AddRooms(ctx context.Context, rooms ...*Room) error {
+	return c.do(ctx, func() {
+		for _, room := range rooms {
+			if _, ok := c.Rooms[room.ID]; !ok {
+				c.Rooms[room.ID] = room
+			}
+		}
+	})
+}
+
+// ArchiveRooms makes the live rooms among ids read-only
+func (c *Core) ArchiveRooms(ctx context.Context, ids ...string) error {
+	return c.do(ctx, func() {
+		for _, id := range ids {
+			if room, ok := c.Rooms[id]; ok {
+				room.Archived = true
+			}
+		}
+	})
+}
+
+// IsConnected reports whether the user has an authenticated client in the room
+func (c *Core) IsConnected(ctx context.Context, roomID, userID string) (bool, error) {
+	connected := false
+	err := c.do(ctx, func() {
+		if room, ok := c.Rooms[roomID]; ok {
+			cl, ok := room.Clients[userID]
+			connected = ok && cl.Authenticated
+		}
+	})
+	return connected, err
+}
+
+// RoomClients returns the clients connected to the room, or none if it
+// isn't live
+func (c *Core) RoomClients(ctx context.Context, roomID string) ([]ClientInfo, error) {
+	var clients []ClientInfo
+	err := c.do(ctx, func() {
+		if room, ok := c.Rooms[roomID]; ok {
+			for _, cl := range room.Clients {
+				clients = append(clients, ClientInfo{ID: cl.ID, Username: cl.Username})
+			}
+		}
+	})
+	return clients, err
+}
+
+// Connections returns the live rooms with the most connected clients first
+func (c *Core) Connections(ctx context.Context) (*Connections, error) {
+	conns := &Connections{Rooms: []RoomConnections{}, Draining: c.draining.Load()}
+
+	err := c.do(ctx, func() {
+		for id, room := range c.Rooms {
+			conns.Rooms = append(conns.Rooms, RoomConnections{RoomID: id, Name: room.Name, Clients: len(room.Clients)})
+			conns.TotalClients += len(room.Clients)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(conns.Rooms, func(a, b RoomConnections) int {
+		return b.Clients - a.Clients
+	})
+
+	return conns, nil
+}
+
+// ExpireRoom closes a live room that an admin force-expired. It returns the
+// number of clients that were disconnected.
+func (c *Core) ExpireRoom(ctx context.Context, roomID string) (int, error) {
+	return c.closeLiveRoom(ctx, roomID, "This room has expired", CloseRoomExpired, "room expired")
+}
+
+// DeleteRoom closes a live room that an admin deleted. It returns the number
+// of clients that were disconnected.
+func (c *Core) DeleteRoom(ctx context.Context, roomID string) (int, error) {
+	return c.closeLiveRoom(ctx, roomID, "This room was removed by an administrator", CloseRoomDeleted, "room deleted")
+}
+
+func (c *Core) closeLiveRoom(ctx context.Context, roomID, notice string, code int, reason string) (int, error) {
+	closed := 0
+	err := c.do(ctx, func() {
+		if room, ok := c.Rooms[roomID]; ok {
+			closed = len(room.Clients)
+			c.closeRoom(roomID, room, notice, code, reason)
+		}
+	})
+	return closed, err
+}
+
+// PurgeRoomHistory drops the room's cached history, so clients joining after
+// its messages were deleted aren't replayed them
+func (c *Core) PurgeRoomHistory(ctx context.Context, roomID string) error {
+	return c.do(ctx, func() {
+		if room, ok := c.Rooms[roomID]; ok {
+			room.History = nil
+		}
+	})
+}
+
+// PurgeUserHistory drops the user's messages from every room's cached history
+func (c *Core) PurgeUserHistory(ctx context.Context, userID string) error {
+	return c.do(ctx, func() {
+		for _, room := range c.Rooms {
+			room.History = slices.DeleteFunc(room.History, func(m *Message) bool {
+				return m.UserID == userID
+			})
+		}
+	})
+}
+
+// closeRoom sends notice to every client of the room, closes them with code
+// and drops the room from the registry
+func (c *Core) closeRoom(id string, room *Room, notice string, code int, reason string) {
+	log.Printf("Closing room %s (%s), %d connections", id, reason, len(room.Clients))
+
+	msg := &Message{
+		Content:   notice,
+		RoomID:    id,
+		Username:  "system",
+		System:    true,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	for clientID, cl := range room.Clients {
+		cl.Message <- msg
+		cl.closeWith(code, reason)
+		delete(room.Clients, clientID)
+	}
+
+	delete(c.Rooms, id)
+}
@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"crypto/rand"
 	"log"
 	"time"
 
@@ -13,6 +14,26 @@ type Client struct {
 	ID       string `json:"id"`
 	RoomID   string `json:"room_id"`
 	Username string `json:"username"`
+	// ResumeAfter is the last sequence number the client saw before
+	// reconnecting, or 0 to receive the latest history
+	ResumeAfter int64 `json:"-"`
+	// Codec encodes frames for the negotiated subprotocol; nil means legacy
+	Codec Codec `json:"-"`
+	// IP is the client's address as resolved by the RealIP middleware
+	IP string `json:"-"`
+	// Authenticated is set when ID comes from the request's token. Guests
+	// get an ID from NewGuestID instead.
+	Authenticated bool `json:"-"`
+	// Moderator is set for platform moderators and admins when they join
+	Moderator bool `json:"-"`
+
+	closeFrame []byte
+}
+
+// NewGuestID returns an ID for a client that isn't signed in. It is never a
+// user ID, so sanctions and notices meant for users can't reach guests.
+func NewGuestID() string {
+	return "guest-" + rand.Text()
 }
 
 type Message struct {
@@ -22,6 +43,25 @@ type Message struct {
 	UserID    string `json:"user_id,omitempty"`
 	System    bool   `json:"system"`
 	Timestamp string `json:"timestamp,omitempty"`
+	// Seq is assigned by the core to every message broadcast to a room.
+	// Clients order by it and use gaps to detect missed messages.
+	Seq int64 `json:"seq,omitempty"`
+	// Event and Data describe machine-readable system events, e.g. "room_updated"
+	Event string `json:"event,omitempty"`
+	Data  any    `json:"data,omitempty"`
+	// Authenticated is set when UserID came from the sender's token
+	Authenticated bool `json:"-"`
+
+	sender *Client
+}
+
+// senderUserID returns the user who sent m, or "" unless it came from an
+// authenticated connection
+func (m *Message) senderUserID() string {
+	if !m.Authenticated {
+		return ""
+	}
+	return m.UserID
 }
 
 func (c *Client) ReadMessage(core *Core) {
@@ -39,12 +79,22 @@ func (c *Client) ReadMessage(core *Core) {
 			break
 		}
 
+		content, err := c.codec().DecodeContent(m)
+		if err != nil {
+			log.Printf("Dropping malformed frame from client %s: %v", c.ID, err)
+			continue
+		}
+
 		msg := &Message{
-			Content:   string(m),
+			Content:   content,
 			RoomID:    c.RoomID,
 			Username:  c.Username,
-			UserID:    c.ID,
 			Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+			sender:    c,
+		}
+		// Guests' messages aren't attributed to anyone
+		if c.Authenticated {
+			msg.UserID, msg.Authenticated = c.ID, true
 		}
 
 		core.Broadcast <- msg
@@ -59,9 +109,33 @@ func (c *Client) WriteMessage() {
 	for {
 		message, ok := <-c.Message
 		if !ok {
+			// Queued messages have been flushed, say goodbye if the server asked for it
+			if c.closeFrame != nil {
+				_ = c.Conn.WriteControl(websocket.CloseMessage, c.closeFrame, time.Now().Add(time.Second))
+			}
 			return
 		}
 
-		c.Conn.WriteJSON(message)
+		data, err := c.codec().Encode(message)
+		if err != nil {
+			log.Printf("Failed to encode message for client %s: %v", c.ID, err)
+			continue
+		}
+		c.Conn.WriteMessage(c.codec().FrameType(), data)
 	}
 }
+
+func (c *Client) codec() Codec {
+	if c.Codec == nil {
+		return legacyCodec{}
+	}
+	return c.Codec
+}
+
+// closeWith stops the client's writer after its queued messages are sent
+// and closes the connection with the given close code and reason.
+// It must only be called by the core, which owns the Message channel.
+func (c *Client) closeWith(code int, reason string) {
+	c.closeFrame = websocket.FormatCloseMessage(code, reason)
+	close(c.Message)
+}
@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Subprotocols clients can negotiate through Sec-WebSocket-Protocol.
+// Clients that don't ask for one get the legacy framing: plain text in,
+// JSON out.
+const (
+	ProtocolJSON    = "yappr.v1.json"
+	ProtocolMsgpack = "yappr.v1.msgpack"
+)
+
+// Subprotocols lists the supported subprotocols for the upgrader
+var Subprotocols = []string{ProtocolJSON, ProtocolMsgpack}
+
+// Codec converts between websocket frames and messages for one subprotocol
+type Codec interface {
+	// FrameType is the websocket message type frames are written with
+	FrameType() int
+	Encode(m *Message) ([]byte, error)
+	// DecodeContent extracts the chat text from a frame sent by the client
+	DecodeContent(data []byte) (string, error)
+}
+
+// CodecFor returns the codec for the negotiated subprotocol
+func CodecFor(protocol string) Codec {
+	switch protocol {
+	case ProtocolJSON:
+		return jsonCodec{}
+	case ProtocolMsgpack:
+		return msgpackCodec{}
+	default:
+		return legacyCodec{}
+	}
+}
+
+// inboundMessage is the envelope v1 clients send
+type inboundMessage struct {
+	Content string `json:"content"`
+}
+
+type legacyCodec struct{}
+
+func (legacyCodec) FrameType() int { return websocket.TextMessage }
+
+func (legacyCodec) Encode(m *Message) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (legacyCodec) DecodeContent(data []byte) (string, error) {
+	return string(data), nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) FrameType() int { return websocket.TextMessage }
+
+func (jsonCodec) Encode(m *Message) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (jsonCodec) DecodeContent(data []byte) (string, error) {
+	var in inboundMessage
+	if err := json.Unmarshal(data, &in); err != nil {
+		return "", err
+	}
+	return in.Content, nil
+}
+
+// msgpackCodec reuses the json struct tags so both encodings share field names
+type msgpackCodec struct{}
+
+func (msgpackCodec) FrameType() int { return websocket.BinaryMessage }
+
+func (msgpackCodec) Encode(m *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) DecodeContent(data []byte) (string, error) {
+	var in inboundMessage
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(&in); err != nil {
+		return "", err
+	}
+	return in.Content, nil
+}
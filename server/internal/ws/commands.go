@@ -0,0 +1,70 @@
+package ws
+
+import (
+	"strings"
+	"time"
+)
+
+// handleCommand runs a slash command sent as a chat message.
+// It returns false if the message isn't a known command and should be broadcast as usual.
+func (c *Core) handleCommand(room *Room, m *Message) bool {
+	fields := strings.Fields(m.Content)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "/extend":
+		if room.CreatorID == "" || room.CreatorID != m.senderUserID() {
+			c.notify(room, m, "Only the room creator can extend the room. Use /keepalive to start a vote instead")
+			return true
+		}
+		if err := c.startExtension(room, "the room creator", nil); err != nil {
+			c.notify(room, m, err.Error())
+		}
+		return true
+
+	case "/keepalive":
+		c.castKeepAliveVote(room, m)
+		return true
+	}
+
+	return false
+}
+
+// notify sends a system message to the sender of m without persisting it
+func (c *Core) notify(room *Room, m *Message, content string) {
+	c.reply(room, m, &Message{
+		Content:   content,
+		RoomID:    room.ID,
+		Username:  "system",
+		System:    true,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+// reply delivers a frame to the sender of m only, if it is still connected
+func (c *Core) reply(room *Room, m *Message, frame *Message) {
+	sender := m.sender
+	if sender == nil {
+		sender = room.Clients[m.UserID]
+	}
+
+	// The sender may have disconnected since sending, in which case its channel is closed
+	if sender == nil || room.Clients[sender.ID] != sender {
+		return
+	}
+
+	sender.Message <- frame
+}
+
+// announce broadcasts a system message to the whole room
+func (c *Core) announce(room *Room, content string) {
+	c.broadcast(&Message{
+		Content:   content,
+		RoomID:    room.ID,
+		Username:  "system",
+		System:    true,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
@@ -2,10 +2,14 @@ package ws
 
 import (
 	"context"
-	"database/sql"
 	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
 	statsRepo "github.com/momomo0206/go-chat-app/internal/repo/stats"
 )
@@ -15,78 +19,119 @@ type Room struct {
 	Name             string             `json:"name"`
 	Clients          map[string]*Client `json:"clients"`
 	History          []*Message
-	IsPinned         bool    `json:"is_pinned"`
-	TopicTitle       *string `json:"topic_title,omitempty"`
-	TopicDescription *string `json:"topic_description,omitempty"`
-	TopicURL         *string `json:"topic_url,omitempty"`
-	TopicSource      *string `json:"topic_source,omitempty"`
+	IsPinned         bool      `json:"is_pinned"`
+	TopicTitle       *string   `json:"topic_title,omitempty"`
+	TopicDescription *string   `json:"topic_description,omitempty"`
+	TopicURL         *string   `json:"topic_url,omitempty"`
+	TopicSource      *string   `json:"topic_source,omitempty"`
+	Archived         bool      `json:"archived"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatorID        string    `json:"creator_id,omitempty"`
+	Description      *string   `json:"description,omitempty"`
+	Rules            *string   `json:"rules,omitempty"`
+	SlowModeSeconds  int       `json:"slow_mode_seconds"`
+	// LastSeq is the sequence number of the last message broadcast to the room
+	LastSeq       int64 `json:"last_seq"`
+	lastMessageAt map[string]time.Time
+	warningsSent  int
+	vote          *keepAliveVote
+	extending     bool
 }
 
+// resumeLimit caps how many missed messages a reconnecting client is sent
+const resumeLimit = 500
+
 type Core struct {
-	Rooms      map[string]*Room
-	Register   chan *Client
-	Unregister chan *Client
-	Broadcast  chan *Message
-	roomRepo   *roomRepo.RoomRepository
-	statsRepo  *statsRepo.StatsRepository
-	db         *sql.DB
+	Rooms          map[string]*Room
+	Register       chan *Client
+	Unregister     chan *Client
+	Broadcast      chan *Message
+	roomRepo       roomRepo.RoomStore
+	messageRepo    roomRepo.MessageStore
+	statsRepo      statsRepo.StatsStore
+	writer         *MessageWriter
+	expiryWarnings []time.Duration
+	lifetime       lifetimeConfig
+	extendRequests chan extendRequest
+	extended       chan extendOutcome
+	settings       chan RoomSettings
+	shutdown       chan chan struct{}
+	stop           chan struct{}
+	admin          chan adminRequest
+	muted          map[string]time.Time // user ID -> muted until
+	shadowBanned   map[string]time.Time // user ID -> shadow-banned until, zero for never
+	contentFilter  ContentFilter
+	onBlocked      BlockedHook
+	draining       atomic.Bool
+	pending        sync.WaitGroup
 }
 
-func NewCore(db *sql.DB) *Core {
-	return &Core{
-		Rooms:      make(map[string]*Room),
-		Register:   make(chan *Client),
-		Unregister: make(chan *Client),
-		Broadcast:  make(chan *Message, 5),
-		roomRepo:   roomRepo.NewRoomRepository(db),
-		statsRepo:  statsRepo.NewStatsRepository(db),
-		db:         db,
+func NewCore(rooms roomRepo.RoomStore, messages roomRepo.MessageStore, stats statsRepo.StatsStore) *Core {
+	c := &Core{
+		Rooms:          make(map[string]*Room),
+		Register:       make(chan *Client),
+		Unregister:     make(chan *Client),
+		Broadcast:      make(chan *Message, 5),
+		roomRepo:       rooms,
+		messageRepo:    messages,
+		statsRepo:      stats,
+		expiryWarnings: loadExpiryWarnings(),
+		lifetime:       loadLifetimeConfig(),
+		extendRequests: make(chan extendRequest),
+		extended:       make(chan extendOutcome, 5),
+		settings:       make(chan RoomSettings, 5),
+		shutdown:       make(chan chan struct{}),
+		stop:           make(chan struct{}),
+		admin:          make(chan adminRequest),
+		muted:          make(map[string]time.Time),
+		shadowBanned:   make(map[string]time.Time),
 	}
+	c.writer = NewMessageWriter(messages, stats, c.track)
+
+	return c
+}
+
+// WriterStats reports the state of the message persistence queue
+func (c *Core) WriterStats() WriterStats {
+	return c.writer.Stats()
 }
 
-func (c *Core) GetDB() *sql.DB {
-	return c.db
+// FlushMessages writes the messages waiting in the persistence queue, so
+// deleting messages from the database doesn't miss any still queued
+func (c *Core) FlushMessages(ctx context.Context) error {
+	return c.writer.Flush(ctx)
+}
+
+// ClientCounts returns the number of clients connected to each live room
+func (c *Core) ClientCounts(ctx context.Context) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := c.do(ctx, func() {
+		for id, room := range c.Rooms {
+			counts[id] = len(room.Clients)
+		}
+	})
+	return counts, err
 }
 
 // The core will be ran in a different go Routine
 func (c *Core) Run() {
+	expiryTicker := time.NewTicker(expiryCheckInterval)
+	defer expiryTicker.Stop()
+
 	for {
 		select {
 		case cl := <-c.Register:
+			// Clients that raced the shutdown are turned away straight away
+			if c.draining.Load() {
+				cl.closeWith(websocket.CloseGoingAway, "server restarting")
+				continue
+			}
+
 			if room, ok := c.Rooms[cl.RoomID]; ok {
 				if _, ok := room.Clients[cl.ID]; !ok {
 					room.Clients[cl.ID] = cl
 				}
-				go func() {
-					roomUUID, err := uuid.Parse(cl.RoomID)
-					if err != nil {
-						log.Printf("Invalid room ID: %v", err)
-						return
-					}
-
-					messages, err := c.roomRepo.GetRoomMessages(context.Background(), roomUUID, 100)
-					if err != nil {
-						log.Printf("Failed to load room messages: %v", err)
-						return
-					}
-
-					for _, msg := range messages {
-						userID := ""
-						if msg.UserID != nil {
-							userID = msg.UserID.String()
-						}
-
-						wsMsg := &Message{
-							Content:   msg.Content,
-							RoomID:    cl.RoomID,
-							Username:  msg.Username,
-							UserID:    userID,
-							System:    msg.IsSystem,
-							Timestamp: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-						}
-						cl.Message <- wsMsg
-					}
-				}()
+				c.replayHistory(room, cl)
 			}
 
 		case cl := <-c.Unregister:
@@ -99,53 +144,162 @@ func (c *Core) Run() {
 
 			// FAN OUT
 		case m := <-c.Broadcast:
-			if room, ok := c.Rooms[m.RoomID]; ok {
-				room.History = append(room.History, m)
-
-				go func(msg *Message) {
-					roomUUID, err := uuid.Parse(msg.RoomID)
-					if err != nil {
-						log.Printf("Invalid room ID: %v", err)
-						return
-					}
-
-					var userID *uuid.UUID
-					if msg.UserID != "" {
-						if parsedUserID, err := uuid.Parse(msg.UserID); err == nil {
-							userID = &parsedUserID
-						}
-					}
-
-					dbMsg := &roomRepo.Message{
-						RoomID:   roomUUID,
-						UserID:   userID,
-						Username: msg.Username,
-						Content:  msg.Content,
-						IsSystem: msg.System,
-					}
-
-					if _, err := c.roomRepo.CreateMessage(context.Background(), dbMsg); err != nil {
-						log.Printf("Failed to persist message: %v", err)
-					}
-
-					if userID != nil {
-						if err := c.statsRepo.IncrementMessageCount(context.Background(), *userID); err != nil {
-							log.Printf("Failed to update message count for user %s: %v", userID.String(), err)
-						} else {
-							go func() {
-								_, err := c.statsRepo.CheckAndAwardAchievements(context.Background(), *userID)
-								if err != nil {
-									log.Printf("Error checking achievements for message sender %s: %v", userID.String(), err)
-								}
-							}()
-						}
-					}
-				}(m)
-
-				for _, cl := range room.Clients {
-					cl.Message <- m
-				}
+			c.broadcast(m)
+
+		case req := <-c.extendRequests:
+			c.handleExtendRequest(req)
+
+		case out := <-c.extended:
+			c.handleExtendOutcome(out)
+
+		case update := <-c.settings:
+			c.applyRoomSettings(update)
+
+		case done := <-c.shutdown:
+			c.closeAllClients()
+			close(done)
+
+		case req := <-c.admin:
+			req.fn()
+			close(req.done)
+
+		case now := <-expiryTicker.C:
+			c.expireVotes(now)
+			c.checkRoomExpiry(now)
+
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// broadcast persists the message and fans it out to every client in its room
+func (c *Core) broadcast(m *Message) {
+	if room, ok := c.Rooms[m.RoomID]; ok {
+		// Archived rooms only serve history
+		if room.Archived && !m.System {
+			c.notify(room, m, "This room has expired and is now read-only")
+			return
+		}
+
+		if !m.System && !c.allowUnmuted(room, m, time.Now()) {
+			return
+		}
+
+		if !m.System && strings.HasPrefix(m.Content, "/") && c.handleCommand(room, m) {
+			return
+		}
+
+		if !m.System && !c.allowByContent(room, m, time.Now()) {
+			return
+		}
+
+		if !m.System && !c.allowBySlowMode(room, m, time.Now()) {
+			return
+		}
+
+		if !m.System && c.isShadowBanned(m.senderUserID(), time.Now()) {
+			// Only the sender sees it, and it never reaches the history
+			c.reply(room, m, m)
+			return
+		}
+
+		room.LastSeq++
+		m.Seq = room.LastSeq
+		room.History = append(room.History, m)
+
+		c.persist(m)
+
+		for _, cl := range room.Clients {
+			cl.Message <- m
+		}
+	}
+}
+
+// persist queues the message for the batched writer. It blocks while the
+// writer's queue is full, which slows the core down instead of piling up
+// goroutines.
+func (c *Core) persist(m *Message) {
+	roomUUID, err := uuid.Parse(m.RoomID)
+	if err != nil {
+		log.Printf("Invalid room ID: %v", err)
+		return
+	}
+
+	var userID *uuid.UUID
+	if m.UserID != "" {
+		if parsedUserID, err := uuid.Parse(m.UserID); err == nil {
+			userID = &parsedUserID
+		}
+	}
+
+	c.writer.Enqueue(&roomRepo.Message{
+		RoomID:        roomUUID,
+		UserID:        userID,
+		Username:      m.Username,
+		Content:       m.Content,
+		IsSystem:      m.System,
+		CreatedAt:     time.Now(),
+		Seq:           m.Seq,
+		Authenticated: m.Authenticated && userID != nil,
+	})
+}
+
+// replayHistory sends a newly registered client the messages it hasn't seen.
+// Resuming clients get everything after their last sequence number, from
+// memory when the room's history still covers it; others get the latest
+// page from the database.
+func (c *Core) replayHistory(room *Room, cl *Client) {
+	if cl.ResumeAfter > 0 && len(room.History) > 0 && room.History[0].Seq <= cl.ResumeAfter+1 {
+		var missed []*Message
+		for _, m := range room.History {
+			if m.Seq > cl.ResumeAfter {
+				missed = append(missed, m)
 			}
 		}
+
+		go func() {
+			for _, m := range missed {
+				cl.Message <- m
+			}
+		}()
+		return
 	}
+
+	go func() {
+		roomUUID, err := uuid.Parse(cl.RoomID)
+		if err != nil {
+			log.Printf("Invalid room ID: %v", err)
+			return
+		}
+
+		var messages []*roomRepo.Message
+		if cl.ResumeAfter > 0 {
+			messages, err = c.messageRepo.GetRoomMessagesAfter(context.Background(), roomUUID, cl.ResumeAfter, resumeLimit)
+		} else {
+			messages, err = c.messageRepo.GetRoomMessages(context.Background(), roomUUID, 100)
+		}
+		if err != nil {
+			log.Printf("Failed to load room messages: %v", err)
+			return
+		}
+
+		for _, msg := range messages {
+			userID := ""
+			if msg.UserID != nil {
+				userID = msg.UserID.String()
+			}
+
+			wsMsg := &Message{
+				Content:   msg.Content,
+				RoomID:    cl.RoomID,
+				Username:  msg.Username,
+				UserID:    userID,
+				System:    msg.IsSystem,
+				Timestamp: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+				Seq:       msg.Seq,
+			}
+			cl.Message <- wsMsg
+		}
+	}()
 }
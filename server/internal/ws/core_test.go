@@ -0,0 +1,207 @@
+package ws
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/internal/repo/memory"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+)
+
+// newTestClient returns a signed-in client without a connection whose
+// frames can be read from its Message channel
+func newTestClient(roomID string) *Client {
+	id := uuid.NewString()
+	return &Client{
+		Message:       make(chan *Message, 16),
+		ID:            id,
+		RoomID:        roomID,
+		Username:      id,
+		Authenticated: true,
+	}
+}
+
+// received drains the frames queued for cl
+func received(cl *Client) []*Message {
+	var msgs []*Message
+	for {
+		select {
+		case m := <-cl.Message:
+			msgs = append(msgs, m)
+		default:
+			return msgs
+		}
+	}
+}
+
+// seqs returns the sequence numbers of the chat messages in msgs, leaving
+// out system notices
+func seqs(msgs []*Message) []int64 {
+	var out []int64
+	for _, m := range msgs {
+		if !m.System {
+			out = append(out, m.Seq)
+		}
+	}
+	return out
+}
+
+// The tests call broadcast directly instead of starting Run, so the test
+// goroutine stands in for the core goroutine.
+func TestBroadcastSeq(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the core and room before alice sends her messages
+		setup    func(c *Core, room *Room, alice *Client)
+		sends    int
+		wantSeqs []int64
+		// wantEchoes counts the messages only alice is sent back
+		wantEchoes int
+		wantStored int
+	}{
+		{
+			name:       "fans out in order",
+			sends:      3,
+			wantSeqs:   []int64{1, 2, 3},
+			wantStored: 3,
+		},
+		{
+			name:       "continues from the persisted seq",
+			setup:      func(c *Core, room *Room, alice *Client) { room.LastSeq = 41 },
+			sends:      2,
+			wantSeqs:   []int64{42, 43},
+			wantStored: 2,
+		},
+		{
+			name:  "archived room is read-only",
+			setup: func(c *Core, room *Room, alice *Client) { room.Archived = true },
+			sends: 2,
+		},
+		{
+			name:  "muted sender is held back",
+			setup: func(c *Core, room *Room, alice *Client) { c.muted[alice.ID] = time.Now().Add(time.Hour) },
+			sends: 2,
+		},
+		{
+			name:       "shadow-banned sender only sees their own messages",
+			setup:      func(c *Core, room *Room, alice *Client) { c.shadowBanned[alice.ID] = time.Time{} },
+			sends:      2,
+			wantEchoes: 2,
+		},
+		{
+			name: "unauthenticated sender isn't held to the user's sanctions",
+			setup: func(c *Core, room *Room, alice *Client) {
+				c.muted[alice.ID] = time.Now().Add(time.Hour)
+				alice.Authenticated = false
+			},
+			sends:      2,
+			wantSeqs:   []int64{1, 2},
+			wantStored: 2,
+		},
+		{
+			name:       "slow mode holds back quick repeats",
+			setup:      func(c *Core, room *Room, alice *Client) { room.SlowModeSeconds = 30 },
+			sends:      3,
+			wantSeqs:   []int64{1},
+			wantStored: 1,
+		},
+		{
+			name: "slow mode exempts the creator",
+			setup: func(c *Core, room *Room, alice *Client) {
+				room.SlowModeSeconds = 30
+				room.CreatorID = alice.ID
+			},
+			sends:      3,
+			wantSeqs:   []int64{1, 2, 3},
+			wantStored: 3,
+		},
+		{
+			name: "slow mode exempts platform moderators",
+			setup: func(c *Core, room *Room, alice *Client) {
+				room.SlowModeSeconds = 30
+				alice.Moderator = true
+			},
+			sends:      3,
+			wantSeqs:   []int64{1, 2, 3},
+			wantStored: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			rooms := memory.NewRoomRepository()
+			c := NewCore(rooms, rooms, memory.NewStatsRepository())
+
+			dbRoom, err := rooms.CreateRoom(ctx, &roomRepo.Room{Name: "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			room := &Room{
+				ID:        dbRoom.ID.String(),
+				Name:      dbRoom.Name,
+				Clients:   make(map[string]*Client),
+				ExpiresAt: dbRoom.ExpiresAt,
+			}
+			c.Rooms[room.ID] = room
+
+			alice, bob := newTestClient(room.ID), newTestClient(room.ID)
+			room.Clients[alice.ID] = alice
+			room.Clients[bob.ID] = bob
+
+			if tt.setup != nil {
+				tt.setup(c, room, alice)
+			}
+
+			for range tt.sends {
+				c.broadcast(&Message{
+					Content:       "hello",
+					RoomID:        room.ID,
+					Username:      alice.Username,
+					UserID:        alice.ID,
+					Authenticated: alice.Authenticated,
+					sender:        alice,
+				})
+			}
+
+			if got := seqs(received(bob)); !slices.Equal(got, tt.wantSeqs) {
+				t.Errorf("bob got seqs %v, want %v", got, tt.wantSeqs)
+			}
+			aliceSeqs := seqs(received(alice))
+			if got := aliceSeqs[:min(len(tt.wantSeqs), len(aliceSeqs))]; !slices.Equal(got, tt.wantSeqs) {
+				t.Errorf("alice got seqs %v, want %v", got, tt.wantSeqs)
+			}
+			if echoes := len(aliceSeqs) - len(tt.wantSeqs); echoes != tt.wantEchoes {
+				t.Errorf("alice got %d messages nobody else saw, want %d", echoes, tt.wantEchoes)
+			}
+
+			// Closing the writer flushes everything queued
+			flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if err := c.WaitForPersistence(flushCtx); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := rooms.GetRoomMessages(ctx, dbRoom.ID, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != tt.wantStored {
+				t.Fatalf("stored %d messages, want %d", len(stored), tt.wantStored)
+			}
+			for i, msg := range stored {
+				if msg.UserID == nil || msg.UserID.String() != alice.ID {
+					t.Errorf("stored message %d isn't attributed to alice", msg.Seq)
+				}
+				if msg.Authenticated != alice.Authenticated {
+					t.Errorf("stored message %d authenticated = %v, want %v", msg.Seq, msg.Authenticated, alice.Authenticated)
+				}
+				if want := tt.wantSeqs[i]; msg.Seq != want {
+					t.Errorf("stored message %d has seq %d, want %d", i, msg.Seq, want)
+				}
+			}
+		})
+	}
+}
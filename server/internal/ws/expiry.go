@@ -0,0 +1,92 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+const (
+	// CloseRoomExpired is sent to clients when their room reaches expires_at
+	CloseRoomExpired = 4000
+
+	expiryCheckInterval = 10 * time.Second
+)
+
+// loadExpiryWarnings parses ROOM_EXPIRY_WARNINGS (e.g. "1h,5m") into
+// durations sorted from the earliest warning to the latest
+func loadExpiryWarnings() []time.Duration {
+	raw := util.GetEnv("ROOM_EXPIRY_WARNINGS", "1h,5m")
+
+	var warnings []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(part)
+		if err != nil || d <= 0 {
+			log.Printf("Ignoring invalid room expiry warning %q", part)
+			continue
+		}
+		warnings = append(warnings, d)
+	}
+
+	slices.Sort(warnings)
+	slices.Reverse(warnings)
+	return slices.Compact(warnings)
+}
+
+// checkRoomExpiry warns rooms that are about to expire and shuts down the ones that have
+func (c *Core) checkRoomExpiry(now time.Time) {
+	for id, room := range c.Rooms {
+		// Archived rooms are already past expiry and only serve history
+		if room.Archived || room.ExpiresAt.IsZero() {
+			continue
+		}
+
+		remaining := room.ExpiresAt.Sub(now)
+		if remaining <= 0 {
+			c.expireRoom(id, room)
+			continue
+		}
+
+		// Only announce the most imminent warning that is due, so a room first
+		// loaded 3 minutes before expiry doesn't get the 1 hour warning as well
+		due := false
+		for room.warningsSent < len(c.expiryWarnings) && remaining <= c.expiryWarnings[room.warningsSent] {
+			room.warningsSent++
+			due = true
+		}
+
+		if due {
+			c.announce(room, fmt.Sprintf("This room expires in %s", formatRemaining(remaining)))
+		}
+	}
+}
+
+// expireRoom closes every client of the room and drops it from the registry
+func (c *Core) expireRoom(id string, room *Room) {
+	c.closeRoom(id, room, "This room has expired", CloseRoomExpired, "room expired")
+}
+
+func formatRemaining(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	switch {
+	case minutes <= 1:
+		return "1 minute"
+	case minutes < 60:
+		return fmt.Sprintf("%d minutes", minutes)
+	case minutes == 60:
+		return "1 hour"
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d hours", minutes/60)
+	default:
+		return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+	}
+}
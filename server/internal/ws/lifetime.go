@@ -0,0 +1,257 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+var (
+	ErrRoomNotFound         = errors.New("room not found or expired")
+	ErrNotRoomCreator       = errors.New("only the room creator can extend the room")
+	ErrPinnedRoomExtension  = errors.New("pinned rooms can't be extended")
+	ErrLifetimeCapReached   = errors.New("room has reached its maximum lifetime")
+	ErrExtensionInProgress  = errors.New("room extension already in progress")
+	errExtendRequestTimeout = errors.New("room extension timed out")
+)
+
+// lifetimeConfig controls how far and by whom rooms can be kept alive
+type lifetimeConfig struct {
+	extendBy    time.Duration
+	maxLifetime time.Duration
+	voteQuorum  float64
+	voteWindow  time.Duration
+}
+
+func loadLifetimeConfig() lifetimeConfig {
+	cfg := lifetimeConfig{
+		extendBy:    6 * time.Hour,
+		maxLifetime: 72 * time.Hour,
+		voteQuorum:  0.5,
+		voteWindow:  2 * time.Minute,
+	}
+
+	if d, err := time.ParseDuration(util.GetEnv("ROOM_EXTEND_DURATION", "")); err == nil && d > 0 {
+		cfg.extendBy = d
+	}
+	if d, err := time.ParseDuration(util.GetEnv("ROOM_MAX_LIFETIME", "")); err == nil && d > 0 {
+		cfg.maxLifetime = d
+	}
+	if q, err := strconv.ParseFloat(util.GetEnv("ROOM_VOTE_QUORUM", ""), 64); err == nil && q > 0 && q <= 1 {
+		cfg.voteQuorum = q
+	}
+	if d, err := time.ParseDuration(util.GetEnv("ROOM_VOTE_WINDOW", "")); err == nil && d > 0 {
+		cfg.voteWindow = d
+	}
+
+	return cfg
+}
+
+// keepAliveVote tracks a running vote to extend a room
+type keepAliveVote struct {
+	startedAt time.Time
+	voters    map[string]bool
+}
+
+type extendRequest struct {
+	roomID string
+	userID string
+	reply  chan extendResult
+}
+
+type extendResult struct {
+	expiresAt time.Time
+	err       error
+}
+
+// extendOutcome carries the result of the database update back to the core
+type extendOutcome struct {
+	roomID    string
+	by        string
+	expiresAt *time.Time
+	err       error
+	reply     chan extendResult
+}
+
+// ExtendRoom lets the room creator push back the room's expiry.
+// The request is handled by the core so in-memory state stays consistent.
+func (c *Core) ExtendRoom(ctx context.Context, roomID, userID string) (time.Time, error) {
+	req := extendRequest{
+		roomID: roomID,
+		userID: userID,
+		reply:  make(chan extendResult, 1),
+	}
+
+	select {
+	case c.extendRequests <- req:
+	case <-ctx.Done():
+		return time.Time{}, errExtendRequestTimeout
+	}
+
+	select {
+	case res := <-req.reply:
+		return res.expiresAt, res.err
+	case <-ctx.Done():
+		return time.Time{}, errExtendRequestTimeout
+	}
+}
+
+func (c *Core) handleExtendRequest(req extendRequest) {
+	room, ok := c.Rooms[req.roomID]
+	if !ok || room.Archived {
+		req.reply <- extendResult{err: ErrRoomNotFound}
+		return
+	}
+	if room.CreatorID == "" || room.CreatorID != req.userID {
+		req.reply <- extendResult{err: ErrNotRoomCreator}
+		return
+	}
+
+	if err := c.startExtension(room, "the room creator", req.reply); err != nil {
+		req.reply <- extendResult{err: err}
+	}
+}
+
+// startExtension updates expires_at in the background and reports back to
+// the core through the extended channel
+func (c *Core) startExtension(room *Room, by string, reply chan extendResult) error {
+	if room.IsPinned {
+		return ErrPinnedRoomExtension
+	}
+	if room.extending {
+		return ErrExtensionInProgress
+	}
+
+	roomUUID, err := uuid.Parse(room.ID)
+	if err != nil {
+		return ErrRoomNotFound
+	}
+
+	room.extending = true
+	cfg := c.lifetime
+
+	c.track(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		expiresAt, err := c.roomRepo.ExtendRoom(ctx, roomUUID, cfg.extendBy, cfg.maxLifetime)
+		c.extended <- extendOutcome{
+			roomID:    room.ID,
+			by:        by,
+			expiresAt: expiresAt,
+			err:       err,
+			reply:     reply,
+		}
+	})
+
+	return nil
+}
+
+func (c *Core) handleExtendOutcome(out extendOutcome) {
+	result := extendResult{err: out.err}
+	defer func() {
+		if out.reply != nil {
+			out.reply <- result
+		}
+	}()
+
+	room, ok := c.Rooms[out.roomID]
+	if !ok {
+		if result.err == nil {
+			result.err = ErrRoomNotFound
+		}
+		return
+	}
+	room.extending = false
+
+	if out.err != nil {
+		log.Printf("Failed to extend room %s: %v", out.roomID, out.err)
+		return
+	}
+	if out.expiresAt == nil {
+		result.err = ErrRoomNotFound
+		return
+	}
+	if !out.expiresAt.After(room.ExpiresAt) {
+		result.err = ErrLifetimeCapReached
+		c.announce(room, "This room has reached its maximum lifetime and can't be extended")
+		return
+	}
+
+	room.ExpiresAt = *out.expiresAt
+	room.warningsSent = 0
+	result.expiresAt = *out.expiresAt
+
+	c.announce(room, fmt.Sprintf("This room was extended by %s and now expires at %s",
+		out.by, out.expiresAt.UTC().Format("2006-01-02 15:04 MST")))
+}
+
+// castKeepAliveVote records a vote to keep the room alive and starts the
+// extension once a quorum of the connected members agrees
+func (c *Core) castKeepAliveVote(room *Room, m *Message) {
+	userID := m.senderUserID()
+	if userID == "" {
+		c.notify(room, m, "Sign in to vote on keeping this room alive")
+		return
+	}
+	if room.IsPinned {
+		c.notify(room, m, ErrPinnedRoomExtension.Error())
+		return
+	}
+
+	if room.vote == nil {
+		room.vote = &keepAliveVote{
+			startedAt: time.Now(),
+			voters:    make(map[string]bool),
+		}
+	} else if room.vote.voters[userID] {
+		c.notify(room, m, "You already voted to keep this room alive")
+		return
+	}
+	room.vote.voters[userID] = true
+
+	votes := len(room.vote.voters)
+	needed := c.votesNeeded(room)
+	if votes < needed {
+		if votes == 1 {
+			c.announce(room, fmt.Sprintf("%s started a vote to keep this room alive. Type /keepalive to agree (%d of %d votes)", m.Username, votes, needed))
+		} else {
+			c.announce(room, fmt.Sprintf("%s voted to keep this room alive (%d of %d votes)", m.Username, votes, needed))
+		}
+		return
+	}
+
+	room.vote = nil
+	if err := c.startExtension(room, "a member vote", nil); err != nil {
+		c.announce(room, fmt.Sprintf("The vote passed but the room couldn't be extended: %v", err))
+	}
+}
+
+// votesNeeded is the quorum of currently connected members
+func (c *Core) votesNeeded(room *Room) int {
+	// Guests can't vote, so only signed-in members count
+	present := 0
+	for _, cl := range room.Clients {
+		if cl.Authenticated {
+			present++
+		}
+	}
+	return max(1, int(math.Ceil(float64(present)*c.lifetime.voteQuorum)))
+}
+
+// expireVotes closes keep-alive votes that didn't reach quorum in time
+func (c *Core) expireVotes(now time.Time) {
+	for _, room := range c.Rooms {
+		if room.vote != nil && now.Sub(room.vote.startedAt) > c.lifetime.voteWindow {
+			room.vote = nil
+			c.announce(room, "The vote to keep this room alive didn't reach enough votes")
+		}
+	}
+}
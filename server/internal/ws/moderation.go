@@ -0,0 +1,252 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+const (
+	// CloseBanned is sent to clients of a user who was just banned
+	CloseBanned = 4002
+
+	EventMessageDeleted = "message_deleted"
+	EventReportResolved = "report_resolved"
+)
+
+// MessageDeletedData is the payload of a message_deleted event
+type MessageDeletedData struct {
+	Seq int64 `json:"seq"`
+}
+
+// MuteUser stops the user's messages from being broadcast until the given
+// time and tells their connected clients
+func (c *Core) MuteUser(ctx context.Context, userID string, until time.Time) error {
+	return c.do(ctx, func() {
+		c.muted[userID] = until
+
+		c.sendToUser(userID, &Message{
+			Content:   fmt.Sprintf("You have been muted until %s", until.UTC().Format("2006-01-02 15:04 MST")),
+			Username:  "system",
+			System:    true,
+			Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+		})
+	})
+}
+
+// UnmuteUser lifts a mute early
+func (c *Core) UnmuteUser(ctx context.Context, userID string) error {
+	return c.do(ctx, func() {
+		if _, ok := c.muted[userID]; !ok {
+			return
+		}
+		delete(c.muted, userID)
+
+		c.sendToUser(userID, &Message{
+			Content:   "You have been unmuted by a moderator",
+			Username:  "system",
+			System:    true,
+			Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+		})
+	})
+}
+
+// ShadowBanUser makes the user's messages visible only to themselves until
+// the given time, or for good if until is zero. The user is not told.
+func (c *Core) ShadowBanUser(ctx context.Context, userID string, until time.Time) error {
+	return c.do(ctx, func() {
+		c.shadowBanned[userID] = until
+	})
+}
+
+// LiftShadowBan makes the user's messages visible to everyone again
+func (c *Core) LiftShadowBan(ctx context.Context, userID string) error {
+	return c.do(ctx, func() {
+		delete(c.shadowBanned, userID)
+	})
+}
+
+// isShadowBanned reports whether the user's messages should only be echoed
+// back to them. Guests, with an empty userID, never are.
+func (c *Core) isShadowBanned(userID string, now time.Time) bool {
+	if userID == "" {
+		return false
+	}
+	until, ok := c.shadowBanned[userID]
+	if !ok {
+		return false
+	}
+	if !until.IsZero() && !until.After(now) {
+		delete(c.shadowBanned, userID)
+		return false
+	}
+	return true
+}
+
+// allowUnmuted drops messages from muted users and tells them why. Only
+// authenticated senders can be muted.
+func (c *Core) allowUnmuted(room *Room, m *Message, now time.Time) bool {
+	userID := m.senderUserID()
+	if userID == "" {
+		return true
+	}
+	until, ok := c.muted[userID]
+	if !ok {
+		return true
+	}
+	if !until.After(now) {
+		delete(c.muted, userID)
+		return true
+	}
+
+	retryAfter := int(until.Sub(now).Seconds()) + 1
+	c.reply(room, m, &Message{
+		Content:   "You are muted and can't send messages",
+		RoomID:    room.ID,
+		Username:  "system",
+		System:    true,
+		Timestamp: now.Format("2006-01-02T15:04:05Z07:00"),
+		Event:     EventError,
+		Data:      ErrorData{Code: "muted", RetryAfterSeconds: retryAfter},
+	})
+	return false
+}
+
+// DisconnectUser closes every connection of the user with code, after
+// sending them notice. It returns the number of connections closed.
+func (c *Core) DisconnectUser(ctx context.Context, userID, notice string, code int, reason string) (int, error) {
+	closed := 0
+	err := c.do(ctx, func() {
+		msg := &Message{
+			Content:   notice,
+			Username:  "system",
+			System:    true,
+			Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+		}
+
+		for _, room := range c.Rooms {
+			cl, ok := room.Clients[userID]
+			if !ok || !cl.Authenticated {
+				continue
+			}
+
+			roomNotice := *msg
+			roomNotice.RoomID = room.ID
+			cl.Message <- &roomNotice
+			cl.closeWith(code, reason)
+			delete(room.Clients, userID)
+			closed++
+		}
+	})
+	return closed, err
+}
+
+// DisconnectNetwork closes every connection from an address in prefix with
+// code, after sending them notice. It returns the number of connections
+// closed.
+func (c *Core) DisconnectNetwork(ctx context.Context, prefix netip.Prefix, notice string, code int, reason string) (int, error) {
+	closed := 0
+	err := c.do(ctx, func() {
+		for _, room := range c.Rooms {
+			for id, cl := range room.Clients {
+				addr, err := netip.ParseAddr(cl.IP)
+				if err != nil || !prefix.Contains(addr.Unmap()) {
+					continue
+				}
+
+				cl.Message <- &Message{
+					Content:   notice,
+					RoomID:    room.ID,
+					Username:  "system",
+					System:    true,
+					Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+				}
+				cl.closeWith(code, reason)
+				delete(room.Clients, id)
+				closed++
+			}
+		}
+	})
+	return closed, err
+}
+
+// FindMessage returns a copy of a message from the room's in-memory history,
+// for messages that may not have been persisted yet
+func (c *Core) FindMessage(ctx context.Context, roomID string, seq int64) (*Message, error) {
+	var found *Message
+	err := c.do(ctx, func() {
+		room, ok := c.Rooms[roomID]
+		if !ok {
+			return
+		}
+		for _, m := range room.History {
+			if m.Seq == seq {
+				copied := *m
+				copied.sender = nil
+				found = &copied
+				return
+			}
+		}
+	})
+	return found, err
+}
+
+// RemoveMessage drops a deleted message from the room's history and tells
+// its clients to hide it
+func (c *Core) RemoveMessage(ctx context.Context, roomID string, seq int64) error {
+	return c.do(ctx, func() {
+		room, ok := c.Rooms[roomID]
+		if !ok {
+			return
+		}
+
+		room.History = slices.DeleteFunc(room.History, func(m *Message) bool {
+			return m.Seq == seq
+		})
+
+		c.broadcast(&Message{
+			Content:   "A message was removed by a moderator",
+			RoomID:    room.ID,
+			Username:  "system",
+			System:    true,
+			Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+			Event:     EventMessageDeleted,
+			Data:      MessageDeletedData{Seq: seq},
+		})
+	})
+}
+
+// NotifyUser sends a system message to every authenticated client of the user
+func (c *Core) NotifyUser(ctx context.Context, userID string, msg *Message) error {
+	return c.do(ctx, func() {
+		c.sendToUser(userID, msg)
+	})
+}
+
+// NotifyClient sends a system message to the client with the ID, signed in
+// or not
+func (c *Core) NotifyClient(ctx context.Context, clientID string, msg *Message) error {
+	return c.do(ctx, func() {
+		for _, room := range c.Rooms {
+			if cl, ok := room.Clients[clientID]; ok {
+				clientMsg := *msg
+				clientMsg.RoomID = room.ID
+				cl.Message <- &clientMsg
+			}
+		}
+	})
+}
+
+// sendToUser delivers a copy of msg to the user's authenticated client in
+// every room
+func (c *Core) sendToUser(userID string, msg *Message) {
+	for _, room := range c.Rooms {
+		if cl, ok := room.Clients[userID]; ok && cl.Authenticated {
+			roomMsg := *msg
+			roomMsg.RoomID = room.ID
+			cl.Message <- &roomMsg
+		}
+	}
+}
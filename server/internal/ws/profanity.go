@@ -0,0 +1,48 @@
+package ws
+
+import "time"
+
+// ContentFilter flags chat messages that may not be broadcast
+type ContentFilter interface {
+	ContainsProfanity(text string) bool
+}
+
+// BlockedHook is told about every message the content filter dropped.
+// userID is empty unless the sender's connection was authenticated. It runs
+// on its own goroutine, so it may call back into the core.
+type BlockedHook func(clientID, userID, ip string)
+
+// SetContentFilter makes the core drop messages the filter flags and report
+// them to onBlocked. It must be called before Run.
+func (c *Core) SetContentFilter(filter ContentFilter, onBlocked BlockedHook) {
+	c.contentFilter = filter
+	c.onBlocked = onBlocked
+}
+
+// allowByContent drops messages the content filter flags and tells the
+// sender why
+func (c *Core) allowByContent(room *Room, m *Message, now time.Time) bool {
+	if c.contentFilter == nil || !c.contentFilter.ContainsProfanity(m.Content) {
+		return true
+	}
+
+	c.reply(room, m, &Message{
+		Content:   "Your message was not sent because it contains inappropriate content",
+		RoomID:    room.ID,
+		Username:  "system",
+		System:    true,
+		Timestamp: now.Format("2006-01-02T15:04:05Z07:00"),
+		Event:     EventError,
+		Data:      ErrorData{Code: "inappropriate_content"},
+	})
+
+	if c.onBlocked != nil {
+		clientID, ip := "", ""
+		if m.sender != nil {
+			clientID, ip = m.sender.ID, m.sender.IP
+		}
+		userID := m.senderUserID()
+		go c.onBlocked(clientID, userID, ip)
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package ws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const EventRoomUpdated = "room_updated"
+
+// RoomSettings is the new state of a room after its creator edited it
+type RoomSettings struct {
+	RoomID           string   `json:"room_id"`
+	Name             string   `json:"name"`
+	Description      *string  `json:"description,omitempty"`
+	Rules            *string  `json:"rules,omitempty"`
+	TopicTitle       *string  `json:"topic_title,omitempty"`
+	TopicDescription *string  `json:"topic_description,omitempty"`
+	TopicURL         *string  `json:"topic_url,omitempty"`
+	SlowModeSeconds  int      `json:"slow_mode_seconds"`
+	Changed          []string `json:"changed"`
+}
+
+// UpdateRoomSettings hands edited settings to the core, which applies them
+// to the live room and tells its clients
+func (c *Core) UpdateRoomSettings(settings RoomSettings) {
+	c.settings <- settings
+}
+
+func (c *Core) applyRoomSettings(s RoomSettings) {
+	room, ok := c.Rooms[s.RoomID]
+	if !ok {
+		return
+	}
+
+	room.Name = s.Name
+	room.Description = s.Description
+	room.Rules = s.Rules
+	room.TopicTitle = s.TopicTitle
+	room.TopicDescription = s.TopicDescription
+	room.TopicURL = s.TopicURL
+	room.SlowModeSeconds = s.SlowModeSeconds
+
+	if len(s.Changed) == 0 {
+		return
+	}
+
+	c.broadcast(&Message{
+		Content:   fmt.Sprintf("The room creator updated the room %s", strings.Join(s.Changed, ", ")),
+		RoomID:    room.ID,
+		Username:  "system",
+		System:    true,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+		Event:     EventRoomUpdated,
+		Data:      s,
+	})
+}
@@ -0,0 +1,100 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var ErrShutdownTimeout = errors.New("timed out waiting for the core to shut down")
+
+// Draining reports whether the core has stopped accepting new clients
+func (c *Core) Draining() bool {
+	return c.draining.Load()
+}
+
+// Shutdown stops accepting joins, tells every connected client that the
+// server is restarting and closes their sockets with 1001 (going away).
+// Messages already handed to the core are still persisted; use
+// WaitForPersistence to wait for them.
+func (c *Core) Shutdown(ctx context.Context) error {
+	c.draining.Store(true)
+
+	done := make(chan struct{})
+	select {
+	case c.shutdown <- done:
+	case <-ctx.Done():
+		return ErrShutdownTimeout
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ErrShutdownTimeout
+	}
+}
+
+// WaitForPersistence flushes the message writer and blocks until every
+// in-flight write has finished or the context is done
+func (c *Core) WaitForPersistence(ctx context.Context) error {
+	if err := c.writer.Close(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ErrShutdownTimeout
+	}
+}
+
+// Stop makes Run return. Call it once, after Shutdown and
+// WaitForPersistence when clients and messages should be drained first.
+func (c *Core) Stop() {
+	close(c.stop)
+}
+
+// closeAllClients disconnects every client in every room
+func (c *Core) closeAllClients() {
+	notice := &Message{
+		Content:   "The server is restarting, please reconnect in a moment",
+		Username:  "system",
+		System:    true,
+		Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	count := 0
+	for _, room := range c.Rooms {
+		for clientID, cl := range room.Clients {
+			roomNotice := *notice
+			roomNotice.RoomID = room.ID
+
+			cl.Message <- &roomNotice
+			cl.closeWith(websocket.CloseGoingAway, "server restarting")
+			delete(room.Clients, clientID)
+			count++
+		}
+	}
+
+	log.Printf("Closed %d websocket connections for shutdown", count)
+}
+
+// track runs fn in a goroutine that WaitForPersistence waits on
+func (c *Core) track(fn func()) {
+	c.pending.Add(1)
+	go func() {
+		defer c.pending.Done()
+		fn()
+	}()
+}
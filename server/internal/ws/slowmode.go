@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const EventError = "error"
+
+// ErrorData is the payload of an error frame sent to a single client
+type ErrorData struct {
+	Code              string `json:"code"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
+}
+
+// isModerator reports whether the sender of m moderates the room, as its
+// creator or a platform moderator, and is exempt from its limits
+func (r *Room) isModerator(m *Message) bool {
+	userID := m.senderUserID()
+	if userID == "" {
+		return false
+	}
+	return userID == r.CreatorID || (m.sender != nil && m.sender.Moderator)
+}
+
+// slowModeKey identifies the sender for rate limiting. Anonymous clients
+// share an empty user ID, so they are told apart by connection.
+func slowModeKey(m *Message) string {
+	if m.UserID != "" || m.sender == nil {
+		return m.UserID
+	}
+	return fmt.Sprintf("client:%p", m.sender)
+}
+
+// allowBySlowMode enforces the room's minimum interval between messages per
+// user. Violators get an error frame telling them how long to wait.
+func (c *Core) allowBySlowMode(room *Room, m *Message, now time.Time) bool {
+	if room.SlowModeSeconds <= 0 || room.isModerator(m) {
+		return true
+	}
+
+	if room.lastMessageAt == nil {
+		room.lastMessageAt = make(map[string]time.Time)
+	}
+
+	interval := time.Duration(room.SlowModeSeconds) * time.Second
+	key := slowModeKey(m)
+	if last, ok := room.lastMessageAt[key]; ok {
+		if wait := interval - now.Sub(last); wait > 0 {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			c.reply(room, m, &Message{
+				Content:   fmt.Sprintf("Slow mode is on. You can send another message in %ds", retryAfter),
+				RoomID:    room.ID,
+				Username:  "system",
+				System:    true,
+				Timestamp: now.Format("2006-01-02T15:04:05Z07:00"),
+				Event:     EventError,
+				Data:      ErrorData{Code: "slow_mode", RetryAfterSeconds: retryAfter},
+			})
+			return false
+		}
+	}
+
+	room.lastMessageAt[key] = now
+
+	// Forget senders whose interval has passed so the map doesn't grow with every visitor
+	for k, last := range room.lastMessageAt {
+		if now.Sub(last) >= interval {
+			delete(room.lastMessageAt, k)
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,279 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+	statsRepo "github.com/momomo0206/go-chat-app/internal/repo/stats"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+// writerConfig sizes the persistence pipeline
+type writerConfig struct {
+	queueSize     int
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func loadWriterConfig() writerConfig {
+	cfg := writerConfig{
+		queueSize:     1024,
+		batchSize:     100,
+		flushInterval: 250 * time.Millisecond,
+	}
+
+	if n, err := strconv.Atoi(util.GetEnv("MESSAGE_QUEUE_SIZE", "")); err == nil && n > 0 {
+		cfg.queueSize = n
+	}
+	// Each message takes 8 bind parameters and Postgres allows 65535 per statement
+	if n, err := strconv.Atoi(util.GetEnv("MESSAGE_BATCH_SIZE", "")); err == nil && n > 0 && n <= 1000 {
+		cfg.batchSize = n
+	}
+	if d, err := time.ParseDuration(util.GetEnv("MESSAGE_FLUSH_INTERVAL", "")); err == nil && d > 0 {
+		cfg.flushInterval = d
+	}
+
+	return cfg
+}
+
+// WriterStats is a snapshot of the persistence pipeline's counters
+type WriterStats struct {
+	QueueDepth     int   `json:"queue_depth"`
+	QueueCapacity  int   `json:"queue_capacity"`
+	Enqueued       int64 `json:"enqueued"`
+	Persisted      int64 `json:"persisted"`
+	Failed         int64 `json:"failed"`
+	Batches        int64 `json:"batches"`
+	BlockedEnqueue int64 `json:"blocked_enqueues"`
+}
+
+// MessageWriter persists chat messages in batches from a bounded queue.
+// A batch is written with one multi-row INSERT when it reaches batchSize or
+// flushInterval passes, and message counts are aggregated per user. If the
+// INSERT fails its messages are written one at a time, so one bad row only
+// loses itself. When the queue is full Enqueue blocks, pushing back on the
+// core.
+type MessageWriter struct {
+	messageRepo roomRepo.MessageStore
+	statsRepo   statsRepo.StatsStore
+	cfg         writerConfig
+	queue       chan *roomRepo.Message
+	flushes     chan chan struct{}
+	done        chan struct{}
+
+	// mu keeps Enqueue from starting a send after Close. The queue is only
+	// closed once the sends already started are done.
+	mu      sync.RWMutex
+	closed  bool
+	sending sync.WaitGroup
+
+	// track runs follow-up work that shutdown must wait for
+	track func(func())
+
+	enqueued       atomic.Int64
+	persisted      atomic.Int64
+	failed         atomic.Int64
+	batches        atomic.Int64
+	blockedEnqueue atomic.Int64
+}
+
+func NewMessageWriter(messages roomRepo.MessageStore, stats statsRepo.StatsStore, track func(func())) *MessageWriter {
+	cfg := loadWriterConfig()
+	w := &MessageWriter{
+		messageRepo: messages,
+		statsRepo:   stats,
+		cfg:         cfg,
+		queue:       make(chan *roomRepo.Message, cfg.queueSize),
+		flushes:     make(chan chan struct{}),
+		done:        make(chan struct{}),
+		track:       track,
+	}
+
+	go w.run()
+	return w
+}
+
+// Enqueue hands a message to the writer, blocking while the queue is full.
+// Messages enqueued after Close are dropped.
+func (w *MessageWriter) Enqueue(msg *roomRepo.Message) {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		log.Printf("Dropping message for room %s: writer is closed", msg.RoomID)
+		w.failed.Add(1)
+		return
+	}
+	w.sending.Add(1)
+	w.mu.RUnlock()
+	defer w.sending.Done()
+
+	// The lock isn't held while blocked, so Close never waits on a full queue
+	select {
+	case w.queue <- msg:
+	default:
+		w.blockedEnqueue.Add(1)
+		w.queue <- msg
+	}
+	w.enqueued.Add(1)
+}
+
+// Close stops accepting messages and waits until everything queued has been
+// flushed or the context is done
+func (w *MessageWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		go func() {
+			w.sending.Wait()
+			close(w.queue)
+		}()
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ErrShutdownTimeout
+	}
+}
+
+// Flush writes every message enqueued before the call without waiting for
+// the next flush interval. It returns once they are written or ctx is done.
+func (w *MessageWriter) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case w.flushes <- flushed:
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *MessageWriter) Stats() WriterStats {
+	return WriterStats{
+		QueueDepth:     len(w.queue),
+		QueueCapacity:  cap(w.queue),
+		Enqueued:       w.enqueued.Load(),
+		Persisted:      w.persisted.Load(),
+		Failed:         w.failed.Load(),
+		Batches:        w.batches.Load(),
+		BlockedEnqueue: w.blockedEnqueue.Load(),
+	}
+}
+
+func (w *MessageWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*roomRepo.Message, 0, w.cfg.batchSize)
+	for {
+		select {
+		case msg, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+
+			batch = append(batch, msg)
+			if len(batch) >= w.cfg.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+
+		case flushed := <-w.flushes:
+			// Only what is queued now, so a busy queue can't hold the caller
+			for n := len(w.queue); n > 0; n-- {
+				msg, ok := <-w.queue
+				if !ok {
+					break
+				}
+				batch = append(batch, msg)
+				if len(batch) >= w.cfg.batchSize {
+					w.flush(batch)
+					batch = batch[:0]
+				}
+			}
+			w.flush(batch)
+			batch = batch[:0]
+			close(flushed)
+		}
+	}
+}
+
+func (w *MessageWriter) flush(batch []*roomRepo.Message) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	w.batches.Add(1)
+	if err := w.messageRepo.CreateMessages(ctx, batch); err != nil {
+		log.Printf("Failed to persist batch of %d messages, retrying one at a time: %v", len(batch), err)
+		batch = w.persistEach(ctx, batch)
+	}
+	w.persisted.Add(int64(len(batch)))
+
+	counts := make(map[uuid.UUID]int)
+	for _, msg := range batch {
+		if msg.UserID != nil && !msg.IsSystem {
+			counts[*msg.UserID]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	if err := w.statsRepo.IncrementMessageCounts(ctx, counts); err != nil {
+		log.Printf("Failed to update message counts for %d users: %v", len(counts), err)
+		return
+	}
+
+	w.track(func() {
+		for userID := range counts {
+			_, err := w.statsRepo.CheckAndAwardAchievements(context.Background(), userID)
+			if err != nil {
+				log.Printf("Error checking achievements for message sender %s: %v", userID.String(), err)
+			}
+		}
+	})
+}
+
+// persistEach writes the messages of a failed batch one at a time and
+// returns those that were persisted
+func (w *MessageWriter) persistEach(ctx context.Context, batch []*roomRepo.Message) []*roomRepo.Message {
+	persisted := make([]*roomRepo.Message, 0, len(batch))
+	for _, msg := range batch {
+		if err := w.messageRepo.CreateMessages(ctx, []*roomRepo.Message{msg}); err != nil {
+			log.Printf("Failed to persist message %d in room %s: %v", msg.Seq, msg.RoomID, err)
+			w.failed.Add(1)
+			continue
+		}
+		persisted = append(persisted, msg)
+	}
+	return persisted
+}
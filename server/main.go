@@ -2,25 +2,31 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
-	"github.com/momomo0206/go-chat-app/db"
-	migration "github.com/momomo0206/go-chat-app/db/migrations"
+	adminHandler "github.com/momomo0206/go-chat-app/internal/api/handler/admin"
 	coreHandler "github.com/momomo0206/go-chat-app/internal/api/handler/core"
+	moderationHandler "github.com/momomo0206/go-chat-app/internal/api/handler/moderation"
 	statsHandler "github.com/momomo0206/go-chat-app/internal/api/handler/stats"
 	userHandler "github.com/momomo0206/go-chat-app/internal/api/handler/user"
+	"github.com/momomo0206/go-chat-app/internal/filter"
 	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
-	statsRepo "github.com/momomo0206/go-chat-app/internal/repo/stats"
-	repository "github.com/momomo0206/go-chat-app/internal/repo/user"
+	auditService "github.com/momomo0206/go-chat-app/internal/service/audit"
+	moderationService "github.com/momomo0206/go-chat-app/internal/service/moderation"
 	"github.com/momomo0206/go-chat-app/internal/service/pinnedrooms"
 	statsService "github.com/momomo0206/go-chat-app/internal/service/stats"
 	service "github.com/momomo0206/go-chat-app/internal/service/user"
 	"github.com/momomo0206/go-chat-app/internal/ws"
 	"github.com/momomo0206/go-chat-app/router"
+	"github.com/momomo0206/go-chat-app/util"
 )
 
 func main() {
@@ -28,78 +34,221 @@ func main() {
 		log.Println("Warning: .env file not found, using environment variable")
 	}
 
-	dbConn, err := db.NewDatabase()
-	if err != nil {
-		log.Fatalf("Could not initialize DB connection: %s", err)
-	}
-	defer dbConn.Close()
-
-	if err := dbConn.Ping(); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
-	}
-	log.Println("Connected to database successfully")
-
-	// Run migrations
-	if err := migration.RunMigrations(dbConn); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
 	}
+}
 
+// serve runs the HTTP and websocket server until SIGINT or SIGTERM
+func serve() error {
 	// Set up Repositories
-	userRepo := repository.NewUserRepository(dbConn)
-	statsRepository := statsRepo.NewStatsRepository(dbConn)
+	store, err := openStorage(util.GetEnv("ENVIRONMENT", "dev"))
+	if err != nil {
+		return fmt.Errorf("could not initialize storage: %w", err)
+	}
+	defer store.close()
 
 	// Set up Services
-	userService := service.NewUserService(userRepo)
-	statsServ := statsService.NewStatsService(statsRepository)
-	wsService := ws.NewCore(dbConn)
+	statsServ := statsService.NewStatsService(store.stats)
+	wsService := ws.NewCore(store.rooms, store.messages, store.stats)
+	auditServ := auditService.NewAuditService(store.audit)
+	moderationServ := moderationService.NewModerationService(store.moderation, store.users, store.rooms, store.messages, wsService, auditServ)
+	userService := service.NewUserService(store.users, moderationServ)
+
+	profanity, err := filter.NewProfanityFilter(util.GetEnv("PROFANITY_WORDLIST", ""))
+	if err != nil {
+		return fmt.Errorf("could not load profanity word lists: %w", err)
+	}
+	wsService.SetContentFilter(profanity, moderationServ.MessageBlocked)
 
 	// Set up Handlers
-	userHandler := userHandler.NewUserHandler(userService)
-	coreHandler := coreHandler.NewCoreHandler(wsService)
+	userHandler := userHandler.NewUserHandler(userService, moderationServ, profanity)
+	coreHandler := coreHandler.NewCoreHandler(wsService, store.users, store.rooms, store.messages, moderationServ, profanity)
 	statsHand := statsHandler.NewStatsHandler(statsServ)
+	adminHand := adminHandler.NewAdminHandler(wsService, store.users, store.rooms, store.messages, store.stats, auditServ, profanity)
+	moderationHand := moderationHandler.NewModerationHandler(moderationServ)
 
 	go wsService.Run()
 
-	pinnedRoomsService := pinnedrooms.NewPinnedRoomsService(dbConn, wsService)
+	if err := moderationServ.RestoreSanctions(context.Background()); err != nil {
+		return fmt.Errorf("could not restore active sanctions: %w", err)
+	}
+
+	pinnedRoomsService := pinnedrooms.NewPinnedRoomsService(store.rooms, store.topics, wsService)
 	if err := pinnedRoomsService.CheckAndRefreshPinnedRooms(context.Background()); err != nil {
 		log.Printf("Failed to initialize pinned rooms: %v", err)
 	}
 
 	// Start background job to clean up expired rooms
-	go startRoomCleanupJob(dbConn, wsService)
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	cleanupDone := make(chan struct{})
+	go func() {
+		defer close(cleanupDone)
+		startRoomCleanupJob(cleanupCtx, store.rooms, pinnedRoomsService, wsService)
+	}()
+
+	// Pick up edits to the word list file without a restart
+	reloadEvery := 30 * time.Second
+	if d, err := time.ParseDuration(util.GetEnv("PROFANITY_WORDLIST_RELOAD", "")); err == nil && d > 0 {
+		reloadEvery = d
+	}
+	go profanity.Watch(cleanupCtx, reloadEvery)
 
-	router := router.SetupRouter(userHandler, coreHandler, statsHand)
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	router := router.SetupRouter(userHandler, coreHandler, statsHand, adminHand, moderationHand, store.users, moderationServ)
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
 	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-signalCtx.Done():
+		log.Println("Shutdown signal received, draining server...")
+	}
+
+	shutdown(srv, wsService, stopCleanup, cleanupDone)
+	return nil
 }
 
-func startRoomCleanupJob(db *sql.DB, wsCore *ws.Core) {
-	roomRepository := roomRepo.NewRoomRepository(db)
-	pinnedRoomsService := pinnedrooms.NewPinnedRoomsService(db, wsCore)
+// shutdown drains the server in order: stop accepting joins and close sockets,
+// stop the HTTP server, let pending message writes finish, then stop the
+// cleanup job. The database is closed by main once this returns.
+func shutdown(srv *http.Server, wsCore *ws.Core, stopCleanup context.CancelFunc, cleanupDone <-chan struct{}) {
+	timeout := 15 * time.Second
+	if d, err := time.ParseDuration(util.GetEnv("SHUTDOWN_TIMEOUT", "")); err == nil && d > 0 {
+		timeout = d
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := wsCore.Shutdown(ctx); err != nil {
+		log.Printf("Error closing websocket connections: %v", err)
+	}
+
+	// Hijacked websocket connections are not tracked here, they were closed above
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+	}
+
+	if err := wsCore.WaitForPersistence(ctx); err != nil {
+		log.Printf("Some messages may not have been persisted: %v", err)
+	} else {
+		log.Println("All pending messages persisted")
+	}
+
+	stopCleanup()
+	select {
+	case <-cleanupDone:
+	case <-ctx.Done():
+		log.Println("Timed out waiting for the room cleanup job to stop")
+	}
+
+	log.Println("Server stopped")
+}
+
+// roomCleanupConfig controls what happens to rooms once they expire.
+// By default they are deleted along with their messages; with
+// ROOM_EXPIRY_MODE=archive they are kept read-only until ROOM_ARCHIVE_RETENTION passes.
+type roomCleanupConfig struct {
+	archive          bool
+	archiveRetention time.Duration
+}
+
+func loadRoomCleanupConfig() roomCleanupConfig {
+	cfg := roomCleanupConfig{
+		archive:          util.GetEnv("ROOM_EXPIRY_MODE", "delete") == "archive",
+		archiveRetention: 30 * 24 * time.Hour,
+	}
+
+	if retentionStr := util.GetEnv("ROOM_ARCHIVE_RETENTION", ""); retentionStr != "" {
+		if retention, err := time.ParseDuration(retentionStr); err == nil && retention > 0 {
+			cfg.archiveRetention = retention
+		} else {
+			log.Printf("Invalid ROOM_ARCHIVE_RETENTION %q, using %s", retentionStr, cfg.archiveRetention)
+		}
+	}
+
+	return cfg
+}
+
+func startRoomCleanupJob(ctx context.Context, roomRepository roomRepo.RoomStore, pinnedRoomsService *pinnedrooms.PinnedRoomsService, wsCore *ws.Core) {
+	cfg := loadRoomCleanupConfig()
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
 
-	cleanupRooms(roomRepository, pinnedRoomsService)
+	cleanupRooms(ctx, roomRepository, pinnedRoomsService, wsCore, cfg)
 
-	for range ticker.C {
-		cleanupRooms(roomRepository, pinnedRoomsService)
+	for {
+		select {
+		case <-ticker.C:
+			cleanupRooms(ctx, roomRepository, pinnedRoomsService, wsCore, cfg)
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
-func cleanupRooms(roomRepository *roomRepo.RoomRepository, pinnedRoomsService *pinnedrooms.PinnedRoomsService) {
-	ctx := context.Background()
-	deletedCount, err := roomRepository.DeleteExpiredRooms(ctx)
+func cleanupRooms(ctx context.Context, roomRepository roomRepo.RoomStore, pinnedRoomsService *pinnedrooms.PinnedRoomsService, wsCore *ws.Core, cfg roomCleanupConfig) {
+	archivedIDs, err := expireRooms(ctx, roomRepository, cfg)
 	if err != nil {
-		log.Printf("Error deleting expired rooms: %v", err)
+		log.Printf("Error cleaning up expired rooms: %v", err)
 		return
 	}
 
-	if deletedCount > 0 {
-		log.Printf("Deleted %d expired rooms", deletedCount)
+	ids := make([]string, len(archivedIDs))
+	for i, id := range archivedIDs {
+		ids[i] = id.String()
+	}
+	if err := wsCore.ArchiveRooms(ctx, ids...); err != nil {
+		log.Printf("Error archiving live rooms: %v", err)
 	}
 
 	if err := pinnedRoomsService.CheckAndRefreshPinnedRooms(ctx); err != nil {
 		log.Printf("Error refreshing pinned rooms: %v", err)
 	}
 }
+
+// expireRooms deletes expired rooms, or archives them and purges old archives
+// in archive mode. It returns the IDs of the rooms it archived.
+func expireRooms(ctx context.Context, roomRepository roomRepo.RoomStore, cfg roomCleanupConfig) ([]uuid.UUID, error) {
+	if !cfg.archive {
+		deletedCount, err := roomRepository.DeleteExpiredRooms(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("delete expired rooms: %w", err)
+		}
+
+		if deletedCount > 0 {
+			log.Printf("Deleted %d expired rooms", deletedCount)
+		}
+		return nil, nil
+	}
+
+	archivedIDs, err := roomRepository.ArchiveExpiredRooms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("archive expired rooms: %w", err)
+	}
+
+	if len(archivedIDs) > 0 {
+		log.Printf("Archived %d expired rooms", len(archivedIDs))
+	}
+
+	purgedCount, err := roomRepository.PurgeArchivedRooms(ctx, cfg.archiveRetention)
+	if err != nil {
+		log.Printf("Error purging archived rooms: %v", err)
+	} else if purgedCount > 0 {
+		log.Printf("Purged %d archived rooms", purgedCount)
+	}
+
+	return archivedIDs, nil
+}
@@ -6,108 +6,140 @@ import (
 	"net/http"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/momomo0206/go-chat-app/util"
 )
 
-func JWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("jwt")
-		if err != nil {
-			util.WriteError(w, http.StatusUnauthorized, "missing auth token")
-			return
-		}
-
-		tokenString := cookie.Value
-		if tokenString == "" {
-			util.WriteError(w, http.StatusUnauthorized, "missing auth token")
-			return
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(util.GetEnv("secretKey", "")), nil
-		})
+// AccessChecker rejects banned users and addresses
+type AccessChecker interface {
+	CheckAccess(userID uuid.UUID, ip string) error
+}
 
-		if err != nil || !token.Valid {
-			util.WriteError(w, http.StatusUnauthorized, "invalid auth token")
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			util.WriteError(w, http.StatusUnauthorized, "invalid token claims")
-			return
-		}
-
-		userID, ok := claims["id"].(string)
-		if !ok {
-			util.WriteError(w, http.StatusUnauthorized, "invalid user ID in token")
-			return
-		}
-
-		ctx := context.WithValue(r.Context(), "userID", userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// allowed writes a 403 and returns false if the user or the client's
+// address is banned
+func allowed(access AccessChecker, w http.ResponseWriter, r *http.Request, userID string) bool {
+	uid, _ := uuid.Parse(userID)
+	if err := access.CheckAccess(uid, util.ClientIP(r)); err != nil {
+		util.WriteError(w, http.StatusForbidden, err.Error())
+		return false
+	}
+	return true
 }
 
-func OptionalJWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("OptionalJWTAuth: Processing request to %s", r.URL.Path)
+// JWTAuth requires a valid token from a user who isn't banned, on an
+// address that isn't banned
+func JWTAuth(access AccessChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie("jwt")
+			if err != nil {
+				util.WriteError(w, http.StatusUnauthorized, "missing auth token")
+				return
+			}
 
-		cookie, err := r.Cookie("jwt")
-		if err != nil {
-			log.Printf("OptionalJWTAuth: No JWT cookie found: %v", err)
-			next.ServeHTTP(w, r)
-			return
-		}
+			tokenString := cookie.Value
+			if tokenString == "" {
+				util.WriteError(w, http.StatusUnauthorized, "missing auth token")
+				return
+			}
 
-		if cookie.Value == "" {
-			log.Println("OptionalJWTAuth: JWT cookie is empty")
-			next.ServeHTTP(w, r)
-			return
-		}
+			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+					return nil, jwt.ErrSignatureInvalid
+				}
+				return []byte(util.GetEnv("secretKey", "")), nil
+			})
 
-		log.Printf("OptionalJWTAuth: Found JWT cookie")
+			if err != nil || !token.Valid {
+				util.WriteError(w, http.StatusUnauthorized, "invalid auth token")
+				return
+			}
+
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				util.WriteError(w, http.StatusUnauthorized, "invalid token claims")
+				return
+			}
 
-		token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				log.Printf("OptionalJWTAuth: Invalid signing method")
-				return nil, jwt.ErrSignatureInvalid
+			userID, ok := claims["id"].(string)
+			if !ok {
+				util.WriteError(w, http.StatusUnauthorized, "invalid user ID in token")
+				return
 			}
-			secretKey := util.GetEnv("secretKey", "")
-			if secretKey == "" {
-				log.Printf("OptionalJWTAuth: Secret key not found in environment")
-				return nil, jwt.ErrSignatureInvalid
+
+			if !allowed(access, w, r, userID) {
+				return
 			}
-			return []byte(secretKey), nil
+
+			ctx := context.WithValue(r.Context(), "userID", userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
-		if err != nil {
-			log.Printf("OptionalJWTAuth: Error parsing token: %v", err)
-			next.ServeHTTP(w, r)
-			return
-		}
+	}
+}
 
-		if !token.Valid {
-			log.Printf("OptionalJWTAuth: Token is invalid")
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			log.Printf("OptionalJWTAuth: Token claims: %+v", claims)
-			if userID, ok := claims["id"].(string); ok {
-				log.Printf("OptionalJWTAuth: Setting userID in context: %s", userID)
-				ctx := context.WithValue(r.Context(), "userID", userID)
-				r = r.WithContext(ctx)
+// OptionalJWTAuth sets the user when the request carries a valid token.
+// Banned users are rejected rather than treated as guests.
+func OptionalJWTAuth(access AccessChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("OptionalJWTAuth: Processing request to %s", r.URL.Path)
+
+			cookie, err := r.Cookie("jwt")
+			if err != nil {
+				log.Printf("OptionalJWTAuth: No JWT cookie found: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cookie.Value == "" {
+				log.Println("OptionalJWTAuth: JWT cookie is empty")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log.Printf("OptionalJWTAuth: Found JWT cookie")
+
+			token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+					log.Printf("OptionalJWTAuth: Invalid signing method")
+					return nil, jwt.ErrSignatureInvalid
+				}
+				secretKey := util.GetEnv("secretKey", "")
+				if secretKey == "" {
+					log.Printf("OptionalJWTAuth: Secret key not found in environment")
+					return nil, jwt.ErrSignatureInvalid
+				}
+				return []byte(secretKey), nil
+			})
+			if err != nil {
+				log.Printf("OptionalJWTAuth: Error parsing token: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !token.Valid {
+				log.Printf("OptionalJWTAuth: Token is invalid")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				log.Printf("OptionalJWTAuth: Token claims: %+v", claims)
+				if userID, ok := claims["id"].(string); ok {
+					if !allowed(access, w, r, userID) {
+						return
+					}
+					log.Printf("OptionalJWTAuth: Setting userID in context: %s", userID)
+					ctx := context.WithValue(r.Context(), "userID", userID)
+					r = r.WithContext(ctx)
+				} else {
+					log.Printf("OptionalJWTAuth: No 'id' claim found in token")
+				}
 			} else {
-				log.Printf("OptionalJWTAuth: No 'id' claim found in token")
+				log.Printf("OptionalJWTAuth: Failed to parse claims")
 			}
-		} else {
-			log.Printf("OptionalJWTAuth: Failed to parse claims")
-		}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
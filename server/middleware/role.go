@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+// RequireRole only lets through users holding one of the roles. It must run
+// after JWTAuth. The role is read from the database on every request, so a
+// revoked role takes effect without waiting for the token to expire.
+func RequireRole(users userRepo.UserStore, roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userIDStr, ok := r.Context().Value("userID").(string)
+			if !ok {
+				util.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+				return
+			}
+
+			userID, err := uuid.Parse(userIDStr)
+			if err != nil {
+				util.WriteError(w, http.StatusUnauthorized, "invalid user ID in token")
+				return
+			}
+
+			user, err := users.GetUserById(r.Context(), userID)
+			if err != nil {
+				// Users deleted since their token was issued end up here too
+				log.Printf("RequireRole: could not load user %s: %v", userID, err)
+				util.WriteError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+
+			if !slices.Contains(roles, user.Role) {
+				util.WriteError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "userRole", user.Role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
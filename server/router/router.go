@@ -7,13 +7,16 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	adminhandler "github.com/momomo0206/go-chat-app/internal/api/handler/admin"
 	corehandler "github.com/momomo0206/go-chat-app/internal/api/handler/core"
+	moderationhandler "github.com/momomo0206/go-chat-app/internal/api/handler/moderation"
 	statshandler "github.com/momomo0206/go-chat-app/internal/api/handler/stats"
 	userhandler "github.com/momomo0206/go-chat-app/internal/api/handler/user"
+	userRepo "github.com/momomo0206/go-chat-app/internal/repo/user"
 	authmiddleware "github.com/momomo0206/go-chat-app/middleware"
 )
 
-func SetupRouter(userH *userhandler.UserHandler, coreH *corehandler.CoreHandler, statsH *statshandler.StatsHandler) http.Handler {
+func SetupRouter(userH *userhandler.UserHandler, coreH *corehandler.CoreHandler, statsH *statshandler.StatsHandler, adminH *adminhandler.AdminHandler, moderationH *moderationhandler.ModerationHandler, users userRepo.UserStore, access authmiddleware.AccessChecker) http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
@@ -24,7 +27,7 @@ func SetupRouter(userH *userhandler.UserHandler, coreH *corehandler.CoreHandler,
 		AllowedOrigins:   []string{"http://localhost:3000", "http://127.0.0.1:3000", "https://yappr.chat", "http://yappr.chat"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "X-Next-Cursor"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -36,7 +39,7 @@ func SetupRouter(userH *userhandler.UserHandler, coreH *corehandler.CoreHandler,
 
 		// Protected routes
 		u.Group(func(r chi.Router) {
-			r.Use(authmiddleware.JWTAuth)
+			r.Use(authmiddleware.JWTAuth(access))
 			r.Put("/username", userH.UpdateUsername)
 		})
 	})
@@ -44,26 +47,92 @@ func SetupRouter(userH *userhandler.UserHandler, coreH *corehandler.CoreHandler,
 	r.Route("/api/stats", func(s chi.Router) {
 		// Protected routes requiring authentication
 		s.Group(func(r chi.Router) {
-			r.Use(authmiddleware.JWTAuth)
+			r.Use(authmiddleware.JWTAuth(access))
 			r.Post("/checkin", statsH.CheckIn)
 			r.Post("/upvote", statsH.GiveUpvote)
 		})
 
 		// Public routes (with optional auth for viewing permissions)
 		s.Group(func(r chi.Router) {
-			r.Use(authmiddleware.OptionalJWTAuth)
+			r.Use(authmiddleware.OptionalJWTAuth(access))
 			r.Get("/profile/{userId}", statsH.GetUserProfile)
 		})
 	})
 
+	r.Route("/api/rooms", func(rm chi.Router) {
+		// Public routes (with optional auth for archived room history)
+		rm.Group(func(r chi.Router) {
+			r.Use(authmiddleware.OptionalJWTAuth(access))
+			r.Get("/{roomId}/messages", coreH.GetRoomMessages)
+		})
+
+		// Protected routes
+		rm.Group(func(r chi.Router) {
+			r.Use(authmiddleware.JWTAuth(access))
+			r.Get("/{roomId}/export", coreH.ExportRoom)
+			r.Post("/{roomId}/extend", coreH.ExtendRoom)
+			r.Put("/{roomId}", coreH.UpdateRoom)
+		})
+	})
+
+	r.Route("/api/reports", func(rp chi.Router) {
+		rp.Use(authmiddleware.JWTAuth(access))
+		rp.Post("/", moderationH.CreateReport)
+		rp.Get("/mine", moderationH.GetMyReports)
+	})
+
+	r.Route("/api/moderation", func(m chi.Router) {
+		// Moderator and admin routes
+		m.Use(authmiddleware.JWTAuth(access))
+		m.Use(authmiddleware.RequireRole(users, userRepo.RoleModerator, userRepo.RoleAdmin))
+
+		m.Get("/reports", moderationH.ListReports)
+		m.Get("/reports/{reportId}", moderationH.GetReport)
+		m.Post("/reports/{reportId}/resolve", moderationH.ResolveReport)
+
+		m.Get("/sanctions", moderationH.ListSanctions)
+		m.Post("/sanctions", moderationH.CreateSanction)
+		m.Delete("/sanctions/{sanctionId}", moderationH.RevokeSanction)
+	})
+
+	r.Route("/api/admin", func(a chi.Router) {
+		// Admin-only routes
+		a.Use(authmiddleware.JWTAuth(access))
+		a.Use(authmiddleware.RequireRole(users, userRepo.RoleAdmin))
+
+		a.Get("/users", adminH.ListUsers)
+		a.Delete("/users/{userId}", adminH.DeleteUser)
+		a.Put("/users/{userId}/role", adminH.SetUserRole)
+		a.Delete("/users/{userId}/messages", adminH.DeleteUserMessages)
+		a.Post("/users/{userId}/achievements", adminH.GrantAchievement)
+		a.Delete("/users/{userId}/achievements/{achievementId}", adminH.RevokeAchievement)
+
+		a.Delete("/rooms/{roomId}", adminH.DeleteRoom)
+		a.Post("/rooms/{roomId}/expire", adminH.ExpireRoom)
+		a.Delete("/rooms/{roomId}/messages", adminH.DeleteRoomMessages)
+
+		a.Get("/achievements", adminH.GetAchievementTypes)
+		a.Get("/connections", adminH.GetConnections)
+		a.Get("/metrics", coreH.GetMetrics)
+		a.Get("/audit", adminH.GetAuditLog)
+		a.Get("/audit/export", adminH.ExportAuditLog)
+		a.Get("/profanity", adminH.GetWordLists)
+		a.Post("/profanity/reload", adminH.ReloadWordLists)
+		a.Post("/profanity/explain", adminH.ExplainProfanity)
+
+		a.Get("/ip-bans", moderationH.ListIPBans)
+		a.Post("/ip-bans", moderationH.CreateIPBan)
+		a.Delete("/ip-bans/{banId}", moderationH.RevokeIPBan)
+	})
+
 	r.Route("/ws", func(u chi.Router) {
-		// Protected route for creating rooms
+		// Optional auth for creating and joining rooms
 		u.Group(func(r chi.Router) {
-			r.Use(authmiddleware.OptionalJWTAuth)
+			r.Use(authmiddleware.OptionalJWTAuth(access))
 			r.Post("/createRoom", coreH.CreateRoom)
+			r.Get("/joinRoom/{roomId}", coreH.JoinRoom)
 		})
 
-		u.Get("/joinRoom/{roomId}", coreH.JoinRoom)
 		u.Get("/getRooms", coreH.GetRooms)
 		u.Get("/getClients/{roomId}", coreH.GetClients)
 	})
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/momomo0206/go-chat-app/db"
+	migration "github.com/momomo0206/go-chat-app/db/migrations"
+	auditRepo "github.com/momomo0206/go-chat-app/internal/repo/audit"
+	"github.com/momomo0206/go-chat-app/internal/repo/memory"
+	moderationRepo "github.com/momomo0206/go-chat-app/internal/repo/moderation"
+	roomRepo "github.com/momomo0206/go-chat-app/internal/repo/room"
+	"github.com/momomo0206/go-chat-app/internal/repo/sqlite"
+	statsRepo "github.com/momomo0206/go-chat-app/internal/repo/stats"
+	repository "github.com/momomo0206/go-chat-app/internal/repo/user"
+	"github.com/momomo0206/go-chat-app/internal/service/topics"
+	"github.com/momomo0206/go-chat-app/util"
+)
+
+// storage bundles the stores the server runs on
+type storage struct {
+	users    repository.UserStore
+	stats    statsRepo.StatsStore
+	rooms    roomRepo.RoomStore
+	messages roomRepo.MessageStore
+	// moderation holds abuse reports, mutes and bans
+	moderation moderationRepo.ModerationStore
+	// audit is the append-only log of moderation actions
+	audit  auditRepo.AuditStore
+	topics *topics.TopicService
+	close  func() error
+}
+
+// openStorage connects the stores for the environment. ENVIRONMENT=memory
+// keeps everything in process memory so the server runs without Postgres or
+// network access; all data is lost when it stops. Otherwise DB_DRIVER picks
+// Postgres (the default) or a single-file SQLite database at SQLITE_PATH.
+func openStorage(env string) (*storage, error) {
+	if env == "memory" {
+		log.Println("Using in-memory storage, data will not survive a restart")
+
+		rooms := memory.NewRoomRepository()
+		return &storage{
+			users:      memory.NewUserRepository(),
+			stats:      memory.NewStatsRepository(),
+			rooms:      rooms,
+			messages:   rooms,
+			moderation: memory.NewModerationRepository(),
+			audit:      memory.NewAuditRepository(),
+			topics:     topics.NewOfflineTopicService(),
+			close:      func() error { return nil },
+		}, nil
+	}
+
+	driver := util.GetEnv("DB_DRIVER", "postgres")
+	dbConn, err := openDatabase(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations
+	if err := migration.RunMigrations(dbConn, driver); err != nil {
+		dbConn.Close()
+		return nil, fmt.Errorf("run migrations: %w", err)
+	}
+
+	if driver == "sqlite" {
+		rooms := sqlite.NewRoomRepository(dbConn)
+		return &storage{
+			users:      sqlite.NewUserRepository(dbConn),
+			stats:      sqlite.NewStatsRepository(dbConn),
+			rooms:      rooms,
+			messages:   rooms,
+			moderation: sqlite.NewModerationRepository(dbConn),
+			audit:      sqlite.NewAuditRepository(dbConn),
+			topics:     topics.NewTopicService(),
+			close:      dbConn.Close,
+		}, nil
+	}
+
+	rooms := roomRepo.NewRoomRepository(dbConn)
+	return &storage{
+		users:      repository.NewUserRepository(dbConn),
+		stats:      statsRepo.NewStatsRepository(dbConn),
+		rooms:      rooms,
+		messages:   rooms,
+		moderation: moderationRepo.NewModerationRepository(dbConn),
+		audit:      auditRepo.NewAuditRepository(dbConn),
+		topics:     topics.NewTopicService(),
+		close:      dbConn.Close,
+	}, nil
+}
+
+// openDatabase connects to the DB_DRIVER database without running migrations
+func openDatabase(driver string) (*sql.DB, error) {
+	var dbConn *sql.DB
+	var err error
+	switch driver {
+	case "postgres":
+		dbConn, err = db.NewDatabase()
+	case "sqlite":
+		dbConn, err = db.NewSQLiteDatabase()
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q, want postgres or sqlite", driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("initialize DB connection: %w", err)
+	}
+
+	if err := dbConn.Ping(); err != nil {
+		dbConn.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+	log.Println("Connected to database successfully")
+
+	return dbConn, nil
+}
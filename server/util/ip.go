@@ -0,0 +1,19 @@
+package util
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// ClientIP returns the client's address without a port. RemoteAddr has
+// already been replaced by chi's RealIP middleware when the request came
+// through a proxy. It returns "" if the address can't be parsed.
+func ClientIP(r *http.Request) string {
+	if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap().String()
+	}
+	if addr, err := netip.ParseAddr(r.RemoteAddr); err == nil {
+		return addr.Unmap().String()
+	}
+	return ""
+}